@@ -1,8 +1,12 @@
 package nagae
 
+import "sort"
+
 type Actor struct {
 	actorId     ActorId
 	parentScene *Scene
+	enabled     bool
+	tags        map[string]bool
 
 	componentMask ComponentList
 	components    map[ComponentId]Component
@@ -11,6 +15,8 @@ type Actor struct {
 func NewActor(actorId ActorId) *Actor {
 	return &Actor{
 		actorId:    actorId,
+		enabled:    true,
+		tags:       make(map[string]bool),
 		components: make(map[ComponentId]Component),
 	}
 }
@@ -18,6 +24,32 @@ func NewActor(actorId ActorId) *Actor {
 func (a Actor) Id() ActorId         { return a.actorId }
 func (a Actor) ParentScene() *Scene { return a.parentScene }
 
+// disabled actors are skipped by the scene and every system, but stay in the scene
+func (a Actor) Enabled() bool { return a.enabled }
+func (a *Actor) SetEnabled(enabled bool) {
+	a.enabled = enabled
+	a.moved()
+}
+
+// moved tells the scene that the actor's bounds might have changed, so its spatial index catches up before it's next asked
+func (a *Actor) moved() {
+	if a != nil && a.parentScene != nil {
+		a.parentScene.spatialDirty[a] = true
+	}
+}
+
+func (a *Actor) AddTag(tag string)     { a.tags[tag] = true }
+func (a *Actor) RemoveTag(tag string)  { delete(a.tags, tag) }
+func (a Actor) HasTag(tag string) bool { return a.tags[tag] }
+func (a Actor) Tags() []string {
+	tags := make([]string, 0, len(a.tags))
+	for tag := range a.tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
 func (a Actor) GetComponentBySystemType(componentType ComponentSystem) (Component, bool) {
 	if !a.componentMask.CheckComponent(componentType) {
 		return nil, false
@@ -58,6 +90,7 @@ func (a *Actor) AddComponent(component Component) error {
 	component.SetParent(a)
 	a.components[component.Id()] = component
 	a.componentMask = a.componentMask.AddComponent(component.SystemType())
+	a.moved()
 	return nil
 }
 
@@ -66,6 +99,7 @@ func (a *Actor) RemoveComponentByType(componentType ComponentType) error {
 		return ErrComponentNotPresent
 	} else {
 		delete(a.components, component.Id())
+		a.moved()
 		return nil
 	}
 }
@@ -75,6 +109,7 @@ func (a *Actor) RemoveComponentById(componentId ComponentId) error {
 		return ErrComponentNotPresent
 	} else {
 		delete(a.components, component.Id())
+		a.moved()
 		return nil
 	}
 }
@@ -96,3 +131,13 @@ func (a *Actor) Update(dt float64) error {
 	}
 	return nil
 }
+
+// eachComponent calls f on every component, stopping at the first error
+func (a *Actor) eachComponent(f func(component Component) error) error {
+	for _, component := range a.components {
+		if err := f(component); err != nil {
+			return err
+		}
+	}
+	return nil
+}
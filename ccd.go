@@ -0,0 +1,44 @@
+package nagae
+
+// sweepBody limits a continuous body's step to its time of impact with the scene's colliders.
+// it stops just inside whatever it hit, so the collision system still sees the contact
+// (bouncing, friction and listeners) without a big positional correction.
+// the other colliders are gathered once per step, so this is only exact against things that aren't moving
+func (p *physicsSystemImpl) sweepBody(actor *Actor, delta Vec2) Vec2 {
+	system, ok := p.attachedScene.collisionSystem.(*collisionSystemImpl)
+	if !ok {
+		return delta
+	}
+	colliderComp, present := actor.GetComponentBySystemType(ComponentSystemCollider)
+	if !present {
+		return delta
+	}
+	collider := colliderComp.(ComponentCollider)
+	shaped, ok := colliderComp.(shapedCollider)
+	if !ok || collider.Trigger() {
+		return delta
+	}
+	shape, ok := shaped.worldShape()
+	if !ok || delta.Hypot() < shape.minExtent()/2 {
+		// slow enough that the discrete step can't skip over anything
+		return delta
+	}
+
+	if !p.swept {
+		system.gather()
+		p.swept = true
+	}
+	moved := shape.translated(delta)
+	candidates := system.candidates(shape.bounds().Union(moved.bounds()), QueryFilter{Exclude: []*Actor{actor}})
+	obstacles := make([]colliderEntry, 0, len(candidates))
+	for _, entry := range candidates {
+		if p.attachedScene.collisionLayers.shouldCollide(collider, entry.collider) {
+			obstacles = append(obstacles, entry)
+		}
+	}
+	hit, hitSomething := sweepShape(shape, delta, obstacles, nil)
+	if !hitSomething {
+		return delta
+	}
+	return delta.Scaled(hit.fraction).Add(hit.manifold.Normal.Scaled(positionCorrectionSlop / 2))
+}
@@ -0,0 +1,82 @@
+package nagae
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func addTestCollider(t *testing.T, scene *Scene, id ActorId, position Vec2, collider ComponentCollider, bodyType BodyType) (ComponentTransform, ComponentPhysics) {
+	t.Helper()
+	actor := NewActor(id)
+	transform, err := NewComponentTransform()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transform.SetPosition(position)
+	physics, err := NewComponentPhysics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	physics.SetBodyType(bodyType)
+	physics.SetCanSleep(false)
+	actor.AddComponent(transform)
+	actor.AddComponent(collider)
+	actor.AddComponent(physics)
+	scene.AddActor(actor)
+	return transform, physics
+}
+
+// fireAtWall shoots a small ball from the origin at a wall 0.02 thick at x = 5, far enough that it would end up
+// 5 past the wall if nothing stopped it. gives the furthest the ball's centre got
+func fireAtWall(t *testing.T, speed, dt float64, continuous bool) float64 {
+	t.Helper()
+	scene := NewScene("test")
+	wall, err := NewComponentAABBCollider(Vec2{0.02, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addTestCollider(t, scene, "wall", Vec2{5, 0}, wall, BodyStatic)
+	ball, err := NewComponentCircleCollider(0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transform, physics := addTestCollider(t, scene, "ball", Vec2{}, ball, BodyDynamic)
+	physics.SetContinuous(continuous)
+	physics.SetVelocity(Vec2{speed, 0})
+	if err := scene.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	furthest := 0.0
+	for i := 0; i < int(math.Ceil(10/(speed*dt)))+2; i++ {
+		if err := scene.Update(dt); err != nil {
+			t.Fatal(err)
+		}
+		furthest = math.Max(furthest, transform.Position().X)
+	}
+	return furthest
+}
+
+func TestContinuousDoesNotTunnel(t *testing.T) {
+	for _, speed := range []float64{10, 100, 500, 1000, 5000} {
+		for _, dt := range []float64{1.0 / 120, 1.0 / 60, 1.0 / 30, 1.0 / 20} {
+			t.Run(fmt.Sprintf("%v units/s, dt 1/%v", speed, math.Round(1/dt)), func(t *testing.T) {
+				// the ball's centre should reach the wall's near face less its radius, and get no further (give or take slop)
+				furthest := fireAtWall(t, speed, dt, true)
+				if furthest > 4.99-0.05+0.01 {
+					t.Errorf("ball got to x = %v, through the wall at 5", furthest)
+				} else if furthest < 4.99-0.05-0.01 {
+					t.Errorf("ball only got to x = %v, short of the wall at 5", furthest)
+				}
+			})
+		}
+	}
+}
+
+func TestDiscreteTunnels(t *testing.T) {
+	// a step of 1000/60 units jumps right over a 0.02 wall, which is why continuous exists
+	if furthest := fireAtWall(t, 1000, 1.0/60, false); furthest < 5 {
+		t.Errorf("discrete ball stopped at x = %v, expected it to tunnel through the wall at 5", furthest)
+	}
+}
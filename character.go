@@ -0,0 +1,284 @@
+package nagae
+
+import "math"
+
+// CharacterConfig tunes a character controller. up is always -Y (screen space)
+type CharacterConfig struct {
+	MoveSpeed     float64
+	JumpSpeed     float64
+	MaxSlopeAngle float64 // radians. anything steeper is a wall
+	CoyoteTime    float64 // seconds after walking off a ledge that a jump still works
+	SnapDistance  float64 // how far down to look for ground when walking down slopes
+	Gravity       Vec2    // only used if the actor has no ComponentPhysics
+	Collision     QueryFilter
+}
+
+func DefaultCharacterConfig() CharacterConfig {
+	return CharacterConfig{
+		MoveSpeed:     3,
+		JumpSpeed:     6,
+		MaxSlopeAngle: math.Pi / 4,
+		CoyoteTime:    0.1,
+		SnapDistance:  0.1,
+		Gravity:       Vec2{0, 15},
+	}
+}
+
+// ComponentCharacterController is a kinematic platformer mover. it sweeps the actor's collider through the
+// scene's colliders (tilemap geometry goes in as AABB colliders), sliding along walls and slopes.
+// if the actor has a ComponentPhysics it's made kinematic, its gravity is used and its velocity kept in sync
+type ComponentCharacterController interface {
+	Component
+
+	Config() CharacterConfig
+	SetConfig(config CharacterConfig)
+
+	Move(direction float64) // -1 to 1, held until changed
+	Jump() bool             // false if not on (or just off) the ground
+
+	Velocity() Vec2
+	SetVelocity(velocity Vec2)
+
+	IsGrounded() bool
+	IsTouchingWall() bool
+	IsTouchingCeiling() bool
+	GroundNormal() Vec2
+	WallNormal() Vec2
+	Ground() *Actor
+}
+
+const characterMaxSlides = 4
+
+type componentCharacterControllerImpl struct {
+	ComponentImpl
+
+	config    CharacterConfig
+	moveInput float64
+	velocity  Vec2
+
+	time           float64
+	lastGroundTime float64
+	jumpRequested  bool
+	jumped         bool
+
+	grounded, wall, ceiling  bool
+	groundNormal, wallNormal Vec2
+	ground                   *Actor
+	groundLastPos            Vec2
+}
+
+func (c componentCharacterControllerImpl) Config() CharacterConfig           { return c.config }
+func (c *componentCharacterControllerImpl) SetConfig(config CharacterConfig) { c.config = config }
+func (c *componentCharacterControllerImpl) Move(direction float64) {
+	c.moveInput = math.Max(-1, math.Min(1, direction))
+}
+func (c componentCharacterControllerImpl) Velocity() Vec2             { return c.velocity }
+func (c *componentCharacterControllerImpl) SetVelocity(velocity Vec2) { c.velocity = velocity }
+func (c componentCharacterControllerImpl) IsGrounded() bool           { return c.grounded }
+func (c componentCharacterControllerImpl) IsTouchingWall() bool       { return c.wall }
+func (c componentCharacterControllerImpl) IsTouchingCeiling() bool    { return c.ceiling }
+func (c componentCharacterControllerImpl) GroundNormal() Vec2         { return c.groundNormal }
+func (c componentCharacterControllerImpl) WallNormal() Vec2           { return c.wallNormal }
+func (c componentCharacterControllerImpl) Ground() *Actor             { return c.ground }
+
+func (c *componentCharacterControllerImpl) Jump() bool {
+	if c.jumped || c.time-c.lastGroundTime > c.config.CoyoteTime {
+		return false
+	}
+	c.jumpRequested = true
+	return true
+}
+
+func (c *componentCharacterControllerImpl) Init() error {
+	if c.boundActor == nil {
+		return nil
+	}
+	if physicsComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemPhysics); present {
+		physicsComp.(ComponentPhysics).SetBodyType(BodyKinematic)
+	}
+	return nil
+}
+
+// parts gets everything the controller needs off its actor
+func (c *componentCharacterControllerImpl) parts() (ComponentTransform, shapedCollider, *collisionSystemImpl, bool) {
+	if c.boundActor == nil || c.boundActor.parentScene == nil {
+		return nil, nil, nil, false
+	}
+	transformComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemTransform)
+	if !present {
+		return nil, nil, nil, false
+	}
+	colliderComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemCollider)
+	if !present {
+		return nil, nil, nil, false
+	}
+	shaped, ok := colliderComp.(shapedCollider)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	system, ok := c.boundActor.parentScene.collisionSystem.(*collisionSystemImpl)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	return transformComp.(ComponentTransform), shaped, system, true
+}
+
+func (c *componentCharacterControllerImpl) Update(dt float64) error {
+	transform, shaped, system, ok := c.parts()
+	if !ok {
+		return nil
+	}
+	c.time += dt
+
+	gravity := c.config.Gravity
+	var physics ComponentPhysics
+	if physicsComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemPhysics); present {
+		physics = physicsComp.(ComponentPhysics)
+		gravity = physics.Gravity().Scaled(physics.GravityScale())
+	}
+
+	// ride whatever we're standing on
+	if c.ground != nil && c.ground.parentScene == c.boundActor.parentScene {
+		if groundTransform, present := c.ground.GetComponentBySystemType(ComponentSystemTransform); present {
+			transform.Translate(groundTransform.(ComponentTransform).Position().Sub(c.groundLastPos))
+		}
+	}
+
+	c.velocity.X = c.moveInput * c.config.MoveSpeed
+	if c.grounded && !c.jumpRequested {
+		// standing on walkable ground, only the part of gravity pushing into it applies.
+		// the rest would slide an idle character down slopes
+		c.velocity.Y = 0
+		c.velocity.Translate(c.groundNormal.Scaled(gravity.Dot(c.groundNormal) * dt))
+	} else {
+		c.velocity.Translate(gravity.Scaled(dt))
+	}
+	if c.jumpRequested {
+		c.velocity.Y = -c.config.JumpSpeed
+		c.jumped = true
+		c.jumpRequested = false
+	}
+
+	wasGrounded := c.grounded
+	c.grounded, c.wall, c.ceiling = false, false, false
+	c.ground = nil
+	c.moveAndSlide(transform, shaped, system, c.velocity.Scaled(dt))
+
+	if wasGrounded && !c.grounded && c.velocity.Y >= 0 && c.config.SnapDistance > 0 {
+		c.snapToGround(transform, shaped, system)
+	}
+	if c.grounded {
+		c.lastGroundTime = c.time
+		if c.velocity.Y >= 0 {
+			c.jumped = false
+		}
+		if groundTransform, present := c.ground.GetComponentBySystemType(ComponentSystemTransform); present {
+			c.groundLastPos = groundTransform.(ComponentTransform).Position()
+		}
+	}
+	if physics != nil {
+		physics.SetVelocity(c.velocity)
+	}
+	return nil
+}
+
+func (c *componentCharacterControllerImpl) obstacles(shape convexShape, motion Vec2, system *collisionSystemImpl) []colliderEntry {
+	filter := c.config.Collision
+	filter.IncludeTriggers = false
+	filter.Exclude = append(append([]*Actor(nil), filter.Exclude...), c.boundActor)
+	moved := shape.translated(motion)
+	candidates := system.candidates(shape.bounds().Union(moved.bounds()), filter)
+
+	colliderComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemCollider)
+	if !present {
+		return candidates
+	}
+	self := colliderComp.(ComponentCollider)
+	obstacles := make([]colliderEntry, 0, len(candidates))
+	for _, entry := range candidates {
+		if system.attachedScene.collisionLayers.shouldCollide(self, entry.collider) {
+			obstacles = append(obstacles, entry)
+		}
+	}
+	return obstacles
+}
+
+// oneWayFilter lets the character through one way platforms unless it's falling onto them from above
+func oneWayFilter(start convexShape, motion Vec2) sweepFilter {
+	startBounds := start.bounds()
+	return func(entry colliderEntry, manifold ContactManifold) bool {
+		if !entry.collider.OneWay() {
+			return true
+		}
+		return motion.Y > 0 && manifold.Normal.Y > 0 && startBounds.Max.Y <= entry.bounds.Min.Y+1e-3
+	}
+}
+
+func (c *componentCharacterControllerImpl) moveAndSlide(transform ComponentTransform, shaped shapedCollider, system *collisionSystemImpl, motion Vec2) {
+	minGroundY := -math.Cos(c.config.MaxSlopeAngle)
+	for i := 0; i < characterMaxSlides && motion.Hypot() > 1e-9; i++ {
+		shape, ok := shaped.worldShape()
+		if !ok {
+			return
+		}
+		hit, hitSomething := sweepShape(shape, motion, c.obstacles(shape, motion, system), oneWayFilter(shape, motion))
+		if !hitSomething {
+			transform.Translate(motion)
+			return
+		}
+		transform.Translate(motion.Scaled(hit.fraction))
+
+		// normal out of whatever we hit
+		normal := hit.manifold.Normal.Scaled(-1)
+		switch {
+		case normal.Y <= minGroundY:
+			c.grounded = true
+			c.groundNormal = normal
+			c.ground = hit.entry.actor
+		case normal.Y >= -minGroundY:
+			c.ceiling = true
+		default:
+			c.wall = true
+			c.wallNormal = normal
+		}
+
+		remaining := motion.Scaled(1 - hit.fraction)
+		motion = remaining.Sub(normal.Scaled(remaining.Dot(normal)))
+		if into := c.velocity.Dot(normal); into < 0 {
+			c.velocity = c.velocity.Sub(normal.Scaled(into))
+		}
+	}
+}
+
+// snapToGround keeps the character stuck to slopes it walks down instead of bouncing off them
+func (c *componentCharacterControllerImpl) snapToGround(transform ComponentTransform, shaped shapedCollider, system *collisionSystemImpl) {
+	shape, ok := shaped.worldShape()
+	if !ok {
+		return
+	}
+	probe := Vec2{0, c.config.SnapDistance}
+	hit, hitSomething := sweepShape(shape, probe, c.obstacles(shape, probe, system), oneWayFilter(shape, probe))
+	if !hitSomething {
+		return
+	}
+	normal := hit.manifold.Normal.Scaled(-1)
+	if normal.Y > -math.Cos(c.config.MaxSlopeAngle) {
+		return
+	}
+	transform.Translate(probe.Scaled(hit.fraction))
+	c.grounded = true
+	c.groundNormal = normal
+	c.ground = hit.entry.actor
+}
+
+func NewComponentCharacterController(config CharacterConfig) (ComponentCharacterController, error) {
+	baseComponent, err := NewComponent(ComponentSystemCustom, ComponentTypeCharacterController, "character controller")
+	if err != nil {
+		return nil, err
+	}
+	return &componentCharacterControllerImpl{
+		ComponentImpl:  *baseComponent.(*ComponentImpl),
+		config:         config,
+		lastGroundTime: math.Inf(-1),
+	}, nil
+}
@@ -0,0 +1,73 @@
+package nagae
+
+import (
+	"math"
+	"testing"
+)
+
+// newTestSlope drops a character controller onto a static wedge rising to the right at the given angle
+func newTestSlope(t *testing.T, angle float64) (*Scene, ComponentTransform, ComponentCharacterController) {
+	t.Helper()
+	scene := NewScene("test")
+	rise := 10 * math.Tan(angle)
+	wedge, err := NewComponentPolygonCollider([]Vec2{{-5, 0}, {-5, 1}, {5, 1}, {5, -rise}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addTestCollider(t, scene, "slope", Vec2{}, wedge, BodyStatic)
+
+	actor := NewActor("character")
+	transform, err := NewComponentTransform()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transform.SetPosition(Vec2{0, -rise/2 - 1})
+	collider, err := NewComponentCapsuleCollider(0.8, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	controller, err := NewComponentCharacterController(DefaultCharacterConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	actor.AddComponent(transform)
+	actor.AddComponent(collider)
+	actor.AddComponent(controller)
+	scene.AddActor(actor)
+	if err := scene.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return scene, transform, controller
+}
+
+func TestCharacterStandsStillOnSlope(t *testing.T) {
+	const dt = 1.0 / 60
+	for _, degrees := range []float64{0, 15, 30, 44} {
+		scene, transform, controller := newTestSlope(t, degrees*math.Pi/180)
+		step(t, scene, dt, 60)
+		if !controller.IsGrounded() {
+			t.Fatalf("%v°: character never landed, at %v", degrees, transform.Position())
+		}
+
+		rest := transform.Position()
+		step(t, scene, dt, 180)
+		if moved := transform.Position().Sub(rest).Hypot(); moved > 1e-6 {
+			t.Errorf("%v°: idle character moved %g from %v to %v", degrees, moved, rest, transform.Position())
+		}
+		if !controller.IsGrounded() {
+			t.Errorf("%v°: idle character left the ground", degrees)
+		}
+
+		// walking still has to work, up the slope and back down it
+		controller.Move(1)
+		step(t, scene, dt, 30)
+		if transform.Position().X <= rest.X {
+			t.Errorf("%v°: walking up the slope went from %v to %v", degrees, rest, transform.Position())
+		}
+		controller.Move(-1)
+		step(t, scene, dt, 60)
+		if transform.Position().X >= rest.X || !controller.IsGrounded() {
+			t.Errorf("%v°: walking down the slope got to %v, grounded %v", degrees, transform.Position(), controller.IsGrounded())
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package nagae
+
+import "sort"
+
+// ChunkBuilder makes a fresh copy of a chunk, so the same room can be loaded into many levels
+type ChunkBuilder func() (*Scene, error)
+
+// ChunkActorId is what an actor from a chunk is called once it's loaded, so the same room can be loaded more than once
+func ChunkActorId(chunkId ChunkId, actorId ActorId) ActorId {
+	return ActorId(string(chunkId) + "/" + string(actorId))
+}
+
+// LoadChunk moves every actor out of chunk and into this scene, shifted by offset.
+// actors are renamed with ChunkActorId. the chunk scene is left empty. if this scene is already running the new actors are initialized
+func (s *Scene) LoadChunk(chunkId ChunkId, chunk *Scene, offset Vec2) error {
+	if _, present := s.chunks[chunkId]; present {
+		return ErrChunkPresent
+	}
+	for actorId := range chunk.actors {
+		if _, present := s.actors[ChunkActorId(chunkId, actorId)]; present {
+			return ErrActorPresent
+		}
+	}
+
+	actorIds := make([]ActorId, 0, len(chunk.actors))
+	for actorId := range chunk.actors {
+		actorIds = append(actorIds, actorId)
+	}
+	sort.Slice(actorIds, func(i, j int) bool { return actorIds[i] < actorIds[j] })
+
+	for i, actorId := range actorIds {
+		actor := chunk.actors[actorId]
+		chunk.RemoveActor(actorId)
+		actorId = ChunkActorId(chunkId, actorId)
+		actor.actorId, actorIds[i] = actorId, actorId
+		if transformComp, present := actor.GetComponentBySystemType(ComponentSystemTransform); present {
+			transformComp.(ComponentTransform).Translate(offset)
+		}
+		s.AddActor(actor)
+		s.actorChunks[actorId] = chunkId
+	}
+	s.chunks[chunkId] = actorIds
+
+	if s.initialized {
+		for _, actorId := range actorIds {
+			if err := s.actors[actorId].Init(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadChunkFrom builds a chunk and loads it
+func (s *Scene) LoadChunkFrom(chunkId ChunkId, builder ChunkBuilder, offset Vec2) error {
+	chunk, err := builder()
+	if err != nil {
+		return err
+	}
+	return s.LoadChunk(chunkId, chunk, offset)
+}
+
+// UnloadChunk removes every actor that came from the chunk and is still in the scene
+func (s *Scene) UnloadChunk(chunkId ChunkId) error {
+	actorIds, present := s.chunks[chunkId]
+	if !present {
+		return ErrChunkNotPresent
+	}
+	for _, actorId := range append([]ActorId(nil), actorIds...) {
+		s.RemoveActor(actorId)
+	}
+	delete(s.chunks, chunkId)
+	return nil
+}
+
+func (s Scene) Chunks() []ChunkId {
+	ids := make([]ChunkId, 0, len(s.chunks))
+	for id := range s.chunks {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (s Scene) ChunkActors(chunkId ChunkId) ([]ActorId, bool) {
+	actorIds, present := s.chunks[chunkId]
+	if !present {
+		return nil, false
+	}
+	return append([]ActorId(nil), actorIds...), true
+}
+
+// ChunkOf tells you which chunk an actor was loaded from
+func (s Scene) ChunkOf(actorId ActorId) (ChunkId, bool) {
+	chunkId, present := s.actorChunks[actorId]
+	return chunkId, present
+}
+
+func (s *Scene) forgetChunkActor(actorId ActorId) {
+	chunkId, present := s.actorChunks[actorId]
+	if !present {
+		return
+	}
+	delete(s.actorChunks, actorId)
+	actorIds := s.chunks[chunkId]
+	for i, id := range actorIds {
+		if id == actorId {
+			s.chunks[chunkId] = append(actorIds[:i:i], actorIds[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package nagae
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ComponentCollider gives an actor a collision shape, positioned by its ComponentTransform
+type ComponentCollider interface {
+	Component
+
+	Offset() Vec2 // relative to the transform, in world units before scaling
+	SetOffset(offset Vec2)
+
+	Bounds() (Rect, bool) // world bounds. false if the actor has no transform
+
+	// triggers don't push anything around, they only report overlaps
+	Trigger() bool
+	SetTrigger(trigger bool)
+
+	Layers() CollisionLayerMask // which layers the collider is on
+	SetLayers(layers CollisionLayerMask)
+	Mask() CollisionLayerMask // which layers the collider can touch
+	SetMask(mask CollisionLayerMask)
+
+	// one way colliders only block character controllers landing on them from above
+	OneWay() bool
+	SetOneWay(oneWay bool)
+}
+
+type componentColliderImpl struct {
+	ComponentImpl
+
+	offset      Vec2
+	local       convexShape // core points are relative to the offset
+	axisAligned bool        // ignores the transform's rotation
+	trigger     bool
+	layers      CollisionLayerMask
+	mask        CollisionLayerMask
+	oneWay      bool
+}
+
+func (c componentColliderImpl) Offset() Vec2 { return c.offset }
+func (c *componentColliderImpl) SetOffset(offset Vec2) {
+	c.offset = offset
+	c.boundActor.moved()
+}
+func (c componentColliderImpl) Trigger() bool                        { return c.trigger }
+func (c *componentColliderImpl) SetTrigger(trigger bool)             { c.trigger = trigger }
+func (c componentColliderImpl) Layers() CollisionLayerMask           { return c.layers }
+func (c *componentColliderImpl) SetLayers(layers CollisionLayerMask) { c.layers = layers }
+func (c componentColliderImpl) Mask() CollisionLayerMask             { return c.mask }
+func (c *componentColliderImpl) SetMask(mask CollisionLayerMask)     { c.mask = mask }
+func (c componentColliderImpl) OneWay() bool                         { return c.oneWay }
+func (c *componentColliderImpl) SetOneWay(oneWay bool)               { c.oneWay = oneWay }
+
+func (c componentColliderImpl) Bounds() (Rect, bool) {
+	shape, ok := c.worldShape()
+	if !ok {
+		return Rect{}, false
+	}
+	return shape.bounds(), true
+}
+
+// worldShape places the local shape using the parent actor's transform
+func (c componentColliderImpl) worldShape() (convexShape, bool) {
+	if c.boundActor == nil {
+		return convexShape{}, false
+	}
+	transformComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemTransform)
+	if !present {
+		return convexShape{}, false
+	}
+	transform := transformComp.(ComponentTransform)
+	pos, scale, rot := transform.Position(), transform.Scale(), transform.Rotation()
+
+	origin := c.offset
+	origin.MultVec(scale)
+	origin.Rotate(rot)
+	origin.Translate(pos)
+
+	points := make([]Vec2, len(c.local.points))
+	for i, p := range c.local.points {
+		p.MultVec(scale)
+		if !c.axisAligned {
+			p.Rotate(rot)
+		}
+		p.Translate(origin)
+		points[i] = p
+	}
+	return convexShape{
+		points: points,
+		radius: c.local.radius * math.Max(math.Abs(scale.X), math.Abs(scale.Y)),
+	}, true
+}
+
+// unitInertia is the moment of inertia per unit mass about the transform's position, with the transform's scale.
+// aabb colliders can't turn, so they give zero
+func (c componentColliderImpl) unitInertia() float64 {
+	if c.axisAligned {
+		return 0
+	}
+	scale := Vec2{1, 1}
+	if c.boundActor != nil {
+		if transformComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemTransform); present {
+			scale = transformComp.(ComponentTransform).Scale()
+		}
+	}
+	points := make([]Vec2, len(c.local.points))
+	for i, p := range c.local.points {
+		p.Translate(c.offset)
+		p.MultVec(scale)
+		points[i] = p
+	}
+	radius := c.local.radius * math.Max(math.Abs(scale.X), math.Abs(scale.Y))
+	return convexShape{points: points, radius: radius}.unitInertia()
+}
+
+func newComponentCollider(componentType ComponentType, baseId string, local convexShape, axisAligned bool) (ComponentCollider, error) {
+	baseComponent, err := NewComponent(ComponentSystemCollider, componentType, baseId)
+	if err != nil {
+		return nil, err
+	}
+	return &componentColliderImpl{
+		ComponentImpl: *baseComponent.(*ComponentImpl),
+		local:         local,
+		axisAligned:   axisAligned,
+		layers:        DefaultCollisionLayer,
+		mask:          AllCollisionLayers,
+	}, nil
+}
+
+// NewComponentAABBCollider is a box that always stays axis aligned, whatever the transform's rotation
+func NewComponentAABBCollider(size Vec2) (ComponentCollider, error) {
+	if size.X <= 0 || size.Y <= 0 {
+		return nil, fmt.Errorf("collider size (%f, %f) must be positive", size.X, size.Y)
+	}
+	hw, hh := size.X/2, size.Y/2
+	return newComponentCollider(ComponentTypeColliderAABB, "aabb collider", convexShape{
+		points: []Vec2{{-hw, -hh}, {hw, -hh}, {hw, hh}, {-hw, hh}},
+	}, true)
+}
+
+func NewComponentCircleCollider(radius float64) (ComponentCollider, error) {
+	if radius <= 0 {
+		return nil, fmt.Errorf("collider radius (%f) must be positive", radius)
+	}
+	return newComponentCollider(ComponentTypeColliderCircle, "circle collider", convexShape{
+		points: []Vec2{{0, 0}},
+		radius: radius,
+	}, false)
+}
+
+// NewComponentPolygonCollider takes the convex hull of the given points
+func NewComponentPolygonCollider(points []Vec2) (ComponentCollider, error) {
+	hull := convexHull(points)
+	if len(hull) < 3 {
+		return nil, fmt.Errorf("polygon collider needs at least 3 non colinear points")
+	}
+	return newComponentCollider(ComponentTypeColliderPolygon, "polygon collider", convexShape{
+		points: hull,
+	}, false)
+}
+
+// NewComponentCapsuleCollider is a vertical capsule. height is end to end, including the rounded caps
+func NewComponentCapsuleCollider(height, radius float64) (ComponentCollider, error) {
+	if radius <= 0 || height < radius*2 {
+		return nil, fmt.Errorf("capsule (height %f, radius %f) must have positive radius and fit its caps", height, radius)
+	}
+	half := height/2 - radius
+	return newComponentCollider(ComponentTypeColliderCapsule, "capsule collider", convexShape{
+		points: []Vec2{{0, -half}, {0, half}},
+		radius: radius,
+	}, false)
+}
+
+// convexHull is a monotone chain hull. returns the hull without repeated points
+func convexHull(points []Vec2) []Vec2 {
+	sorted := append([]Vec2(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X == sorted[j].X {
+			return sorted[i].Y < sorted[j].Y
+		}
+		return sorted[i].X < sorted[j].X
+	})
+	if len(sorted) < 3 {
+		return sorted
+	}
+	hull := make([]Vec2, 0, len(sorted)*2)
+	for pass := 0; pass < 2; pass++ {
+		start := len(hull)
+		for _, p := range sorted {
+			for len(hull) >= start+2 && hull[len(hull)-1].Sub(hull[len(hull)-2]).Cross(p.Sub(hull[len(hull)-2])) <= 0 {
+				hull = hull[:len(hull)-1]
+			}
+			hull = append(hull, p)
+		}
+		hull = hull[:len(hull)-1]
+		for i, j := 0, len(sorted)-1; i < j; i, j = i+1, j-1 {
+			sorted[i], sorted[j] = sorted[j], sorted[i]
+		}
+	}
+	return hull
+}
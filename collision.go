@@ -0,0 +1,291 @@
+package nagae
+
+import "sort"
+
+// Contact is a touching pair found this frame. the manifold normal points from A to B
+type Contact struct {
+	A, B     *Actor
+	Manifold ContactManifold
+}
+
+// Collision is one actor's side of a contact. the manifold normal points from Self to Other
+type Collision struct {
+	Self, Other *Actor
+	Manifold    ContactManifold
+}
+
+// CollisionListener is implemented by components that want to hear about their actor's collisions
+type CollisionListener interface {
+	OnCollision(collision Collision) error
+}
+
+// TriggerEnterListener hears about an actor starting to overlap a trigger (on either side)
+type TriggerEnterListener interface {
+	OnTriggerEnter(other *Actor) error
+}
+
+// TriggerStayListener hears about an overlap with a trigger every frame after it started
+type TriggerStayListener interface {
+	OnTriggerStay(other *Actor) error
+}
+
+// TriggerExitListener hears about an overlap with a trigger ending.
+// this also happens when either actor is removed from the scene or disabled
+type TriggerExitListener interface {
+	OnTriggerExit(other *Actor) error
+}
+
+type actorPair [2]*Actor
+
+func (p actorPair) key() [2]ActorId { return [2]ActorId{p[0].actorId, p[1].actorId} }
+
+// shapedCollider is how the collision system gets a world space shape out of a collider
+type shapedCollider interface {
+	worldShape() (convexShape, bool)
+	unitInertia() float64
+}
+
+type colliderEntry struct {
+	actor    *Actor
+	collider ComponentCollider
+	shape    convexShape
+	bounds   Rect
+}
+
+// CollisionSystem finds touching colliders (broad phase through the scene's spatial index, narrow phase SAT),
+// resolves physics bodies out of each other and tells the actors involved
+type CollisionSystem interface {
+	System
+
+	CellSize() float64 // the scene's spatial index's
+	SetCellSize(size float64)
+	Iterations() int // impulse passes over every contact each step
+	SetIterations(iterations int)
+
+	Contacts() []Contact
+}
+
+type collisionSystemImpl struct {
+	systemImpl
+
+	iterations int
+	entries    []colliderEntry
+	entryIndex map[*Actor]int
+	contacts   []Contact
+	impulses   map[[2]ActorId]contactImpulses // last step's, for warm starting
+
+	triggerOverlaps map[[2]ActorId]actorPair
+}
+
+func NewCollisionSystem(scene *Scene) CollisionSystem {
+	return &collisionSystemImpl{
+		systemImpl: systemImpl{
+			attachedScene: scene,
+		},
+		iterations: 8,
+		entries:    make([]colliderEntry, 0),
+		entryIndex: make(map[*Actor]int),
+		contacts:   make([]Contact, 0),
+		impulses:   make(map[[2]ActorId]contactImpulses),
+
+		triggerOverlaps: make(map[[2]ActorId]actorPair),
+	}
+}
+
+func (c collisionSystemImpl) CellSize() float64         { return c.attachedScene.spatial.CellSize() }
+func (c *collisionSystemImpl) SetCellSize(size float64) { c.attachedScene.spatial.SetCellSize(size) }
+func (c collisionSystemImpl) Iterations() int           { return c.iterations }
+func (c *collisionSystemImpl) SetIterations(iterations int) {
+	if iterations > 0 {
+		c.iterations = iterations
+	}
+}
+func (c collisionSystemImpl) Contacts() []Contact { return c.contacts }
+
+// gather collects every collider in the scene, in actor id order so results are deterministic,
+// and brings the scene's spatial index up to date for the broad phase
+func (c *collisionSystemImpl) gather() {
+	scene := c.attachedScene
+	c.entries = c.entries[:0]
+	c.entryIndex = make(map[*Actor]int, len(c.entries))
+	actorIds := make([]ActorId, 0, len(scene.actors))
+	for actorId := range scene.actors {
+		actorIds = append(actorIds, actorId)
+	}
+	sort.Slice(actorIds, func(i, j int) bool { return actorIds[i] < actorIds[j] })
+
+	for _, actorId := range actorIds {
+		actor := scene.actors[actorId]
+		if !actor.enabled {
+			continue
+		}
+		entry, ok := colliderEntryFor(actor)
+		if !ok {
+			continue
+		}
+		c.entryIndex[actor] = len(c.entries)
+		c.entries = append(c.entries, entry)
+		// colliders can change shape without being moved (animated pixel masks), so they're checked every time
+		if bounds, present := scene.spatial.Bounds(actor); !present || bounds != entry.bounds {
+			scene.spatial.Update(actor, entry.bounds)
+		}
+		delete(scene.spatialDirty, actor)
+	}
+	scene.flushSpatial()
+}
+
+func colliderEntryFor(actor *Actor) (colliderEntry, bool) {
+	colliderComp, present := actor.GetComponentBySystemType(ComponentSystemCollider)
+	if !present {
+		return colliderEntry{}, false
+	}
+	shaped, ok := colliderComp.(shapedCollider)
+	if !ok {
+		return colliderEntry{}, false
+	}
+	shape, ok := shaped.worldShape()
+	if !ok {
+		return colliderEntry{}, false
+	}
+	return colliderEntry{
+		actor:    actor,
+		collider: colliderComp.(ComponentCollider),
+		shape:    shape,
+		bounds:   shape.bounds(),
+	}, true
+}
+
+// refreshEntry moves an actor's gathered collider to where the actor is now, or drops it if it's gone
+func (c *collisionSystemImpl) refreshEntry(actor *Actor) {
+	index, present := c.entryIndex[actor]
+	if !present {
+		return
+	}
+	if entry, ok := colliderEntryFor(actor); ok && actor.enabled {
+		c.entries[index] = entry
+		return
+	}
+	delete(c.entryIndex, actor)
+}
+
+// pairs is every pair of colliders the broad phase puts near each other, in entry order
+func (c *collisionSystemImpl) pairs() [][2]int {
+	pairs := make([][2]int, 0)
+	for _, actors := range c.attachedScene.spatial.pairs(func(actor *Actor) bool {
+		_, present := c.entryIndex[actor]
+		return present
+	}) {
+		pairs = append(pairs, [2]int{c.entryIndex[actors[0]], c.entryIndex[actors[1]]})
+	}
+	return pairs
+}
+
+func (c *collisionSystemImpl) Update(dt float64) error {
+	c.gather()
+	c.contacts = c.contacts[:0]
+	overlaps := make(map[[2]ActorId]actorPair)
+	for _, pair := range c.pairs() {
+		a, b := c.entries[pair[0]], c.entries[pair[1]]
+		if !a.bounds.Overlaps(b.bounds) || !c.attachedScene.collisionLayers.shouldCollide(a.collider, b.collider) {
+			continue
+		}
+		if !a.collider.Trigger() && !b.collider.Trigger() && idlePair(a.actor, b.actor) {
+			// sleeping bodies resting on each other (or on static ones) don't need checking
+			continue
+		}
+		manifold, hit := collideShapes(a.shape, b.shape)
+		if hit {
+			manifold, hit = maskedContact(a, b, manifold)
+		}
+		if !hit {
+			continue
+		}
+		if a.collider.Trigger() || b.collider.Trigger() {
+			actors := actorPair{a.actor, b.actor}
+			overlaps[actors.key()] = actors
+			continue
+		}
+		c.contacts = append(c.contacts, Contact{A: a.actor, B: b.actor, Manifold: manifold})
+	}
+	c.impulses = resolveContacts(c.contacts, c.iterations, c.impulses)
+
+	for _, contact := range c.contacts {
+		if err := notifyCollision(Collision{Self: contact.A, Other: contact.B, Manifold: contact.Manifold}); err != nil {
+			return err
+		}
+		if err := notifyCollision(Collision{Self: contact.B, Other: contact.A, Manifold: contact.Manifold.Flipped()}); err != nil {
+			return err
+		}
+	}
+	return c.updateTriggers(overlaps)
+}
+
+// updateTriggers compares this frame's trigger overlaps to the last frame's.
+// actors that were removed or disabled just stop showing up, so they get their exit here too
+func (c *collisionSystemImpl) updateTriggers(overlaps map[[2]ActorId]actorPair) error {
+	previous := c.triggerOverlaps
+	c.triggerOverlaps = overlaps
+
+	for _, key := range sortedPairKeys(previous) {
+		if _, present := overlaps[key]; present {
+			continue
+		}
+		pair := previous[key]
+		if err := notifyTrigger(pair, func(component Component, other *Actor) error {
+			if listener, ok := component.(TriggerExitListener); ok {
+				return listener.OnTriggerExit(other)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	for _, key := range sortedPairKeys(overlaps) {
+		pair := overlaps[key]
+		_, stayed := previous[key]
+		if err := notifyTrigger(pair, func(component Component, other *Actor) error {
+			if stayed {
+				if listener, ok := component.(TriggerStayListener); ok {
+					return listener.OnTriggerStay(other)
+				}
+			} else if listener, ok := component.(TriggerEnterListener); ok {
+				return listener.OnTriggerEnter(other)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedPairKeys(pairs map[[2]ActorId]actorPair) [][2]ActorId {
+	keys := make([][2]ActorId, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] == keys[j][0] {
+			return keys[i][1] < keys[j][1]
+		}
+		return keys[i][0] < keys[j][0]
+	})
+	return keys
+}
+
+// notifyTrigger runs f over the components of both actors in a pair
+func notifyTrigger(pair actorPair, f func(component Component, other *Actor) error) error {
+	if err := pair[0].eachComponent(func(component Component) error { return f(component, pair[1]) }); err != nil {
+		return err
+	}
+	return pair[1].eachComponent(func(component Component) error { return f(component, pair[0]) })
+}
+
+func notifyCollision(collision Collision) error {
+	return collision.Self.eachComponent(func(component Component) error {
+		if listener, ok := component.(CollisionListener); ok {
+			return listener.OnCollision(collision)
+		}
+		return nil
+	})
+}
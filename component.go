@@ -2,6 +2,7 @@ package nagae
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/hajimehoshi/ebiten"
 )
@@ -88,13 +89,19 @@ type componentGraphicalImpl struct {
 	rotation    float64 // rotation relative to the transform
 }
 
-func (c componentGraphicalImpl) ToDraw() *ebiten.Image  { return nil }
-func (c componentGraphicalImpl) Size() Vec2             { return c.size }
-func (c componentGraphicalImpl) RelativePos() Vec2      { return c.relativePos }
-func (c *componentGraphicalImpl) SetRelativePos(v Vec2) { c.relativePos = v }
-func (c componentGraphicalImpl) Rotation() float64      { return c.rotation }
-func (c *componentGraphicalImpl) SetRotation(r float64) { c.rotation = r } // DANGER DANGER BROKEN MATH
-func (c componentGraphicalImpl) Raw() bool              { return false }
+func (c componentGraphicalImpl) ToDraw() *ebiten.Image { return nil }
+func (c componentGraphicalImpl) Size() Vec2            { return c.size }
+func (c componentGraphicalImpl) RelativePos() Vec2     { return c.relativePos }
+func (c *componentGraphicalImpl) SetRelativePos(v Vec2) {
+	c.relativePos = v
+	c.boundActor.moved()
+}
+func (c componentGraphicalImpl) Rotation() float64 { return c.rotation }
+func (c *componentGraphicalImpl) SetRotation(r float64) { // DANGER DANGER BROKEN MATH
+	c.rotation = r
+	c.boundActor.moved()
+}
+func (c componentGraphicalImpl) Raw() bool { return false }
 
 func NewComponentGraphical(baseId string, drawOrderPos int) (ComponentGraphical, error) {
 	baseComponent, err := NewComponentGraphicalRaw(baseId, drawOrderPos)
@@ -199,17 +206,35 @@ type componentTransformImpl struct {
 	rotation float64
 }
 
-func (c componentTransformImpl) Position() Vec2           { return c.pos }
-func (c *componentTransformImpl) SetPosition(newPos Vec2) { c.pos = newPos }
-func (c *componentTransformImpl) Translate(delta Vec2)    { c.pos.Translate(delta) }
+func (c componentTransformImpl) Position() Vec2 { return c.pos }
+func (c *componentTransformImpl) SetPosition(newPos Vec2) {
+	c.pos = newPos
+	c.boundActor.moved()
+}
+func (c *componentTransformImpl) Translate(delta Vec2) {
+	c.pos.Translate(delta)
+	c.boundActor.moved()
+}
 
-func (c componentTransformImpl) Scale() Vec2              { return c.scale }
-func (c *componentTransformImpl) SetScale(newScale Vec2)  { c.scale = newScale }
-func (c *componentTransformImpl) ScaleBy(percent float64) { c.scale.MultScalar(percent) }
-func (c *componentTransformImpl) ScaleTo(percent float64) { c.scale = Vec2{percent, percent} }
+func (c componentTransformImpl) Scale() Vec2 { return c.scale }
+func (c *componentTransformImpl) SetScale(newScale Vec2) {
+	c.scale = newScale
+	c.boundActor.moved()
+}
+func (c *componentTransformImpl) ScaleBy(percent float64) {
+	c.scale.MultScalar(percent)
+	c.boundActor.moved()
+}
+func (c *componentTransformImpl) ScaleTo(percent float64) {
+	c.scale = Vec2{percent, percent}
+	c.boundActor.moved()
+}
 
-func (c componentTransformImpl) Rotation() float64                { return c.rotation }
-func (c *componentTransformImpl) SetRotation(newRotation float64) { c.rotation = newRotation }
+func (c componentTransformImpl) Rotation() float64 { return c.rotation }
+func (c *componentTransformImpl) SetRotation(newRotation float64) {
+	c.rotation = newRotation
+	c.boundActor.moved()
+}
 
 func NewComponentTransform() (ComponentTransform, error) {
 	baseComponent, err := NewComponent(ComponentSystemTransform, ComponentTypeTransform, "transform")
@@ -222,32 +247,100 @@ func NewComponentTransform() (ComponentTransform, error) {
 	}, nil
 }
 
+// BodyType is how a physics body takes part in the simulation
+type BodyType uint8
+
+const (
+	BodyDynamic   BodyType = iota // moved by forces and collisions
+	BodyKinematic                 // moved only by its velocity, pushes dynamic bodies around
+	BodyStatic                    // never moves
+)
+
 // stores velocity, mass, and applies accelerations
 type ComponentPhysics interface {
 	Component
 
 	Mass() float64
 	SetMass(newMass float64) error
+	InverseMass() float64 // zero for anything that isn't dynamic
+
+	BodyType() BodyType
+	SetBodyType(bodyType BodyType)
 
 	Velocity() Vec2
 	SetVelocity(newVel Vec2)
 
+	Friction() Vec2 // linear damping per axis, per second
 	SetFriction(friction Vec2)
+	Gravity() Vec2
 	SetGravity(accel Vec2)
+	GravityScale() float64
+	SetGravityScale(scale float64)
+	MaxVelocity() float64 // zero means no limit
+	SetMaxVelocity(max float64)
+
+	Restitution() float64 // bounciness in contacts, 0 to 1
+	SetRestitution(restitution float64)
+	SurfaceFriction() float64 // coulomb friction coefficient in contacts
+	SetSurfaceFriction(friction float64)
+
+	// continuous bodies sweep their collider along each step so fast ones can't tunnel through thin colliders
+	Continuous() bool
+	SetContinuous(continuous bool)
+
+	AngularVelocity() float64 // radians per second
+	SetAngularVelocity(newVel float64)
+	AngularDamping() float64 // per second
+	SetAngularDamping(damping float64)
+	Inertia() float64           // worked out from the collider and mass unless set. zero means the body can't be spun
+	SetInertia(inertia float64) // zero goes back to working it out
+	InverseInertia() float64    // zero for anything that isn't dynamic, or has fixed rotation
+	FixedRotation() bool
+	SetFixedRotation(fixed bool)
+
+	// resting bodies fall asleep and stop simulating until something disturbs them.
+	// forces, torques and setting velocities wake them up, moving the transform by hand doesn't.
+	// so does whatever they rest on being removed or disabled, or starting to move if it's kinematic
+	Sleeping() bool
+	WakeUp()
+	CanSleep() bool
+	SetCanSleep(canSleep bool)
 
 	Accelerate(acceleration Vec2)
 	ApplyForce(force Vec2)
+	ApplyTorque(torque float64)
+	ApplyForceAtPoint(force, point Vec2) // point is in world space
 }
 
 type componentPhysicsImpl struct {
 	ComponentImpl
 
 	mass     float64
+	bodyType BodyType
 	velocity Vec2
 	friction Vec2
 	gravity  Vec2
 
+	gravityScale float64
+	maxVelocity  float64
+
+	restitution     float64
+	surfaceFriction float64
+	continuous      bool
+
+	angularVelocity float64
+	angularDamping  float64
+	inertia         float64 // set by hand, zero if worked out
+	fixedRotation   bool
+
+	sleeping  bool
+	sleepTime float64 // how long the body has been still enough to sleep
+	canSleep  bool
+	island    uint64   // the island it fell asleep in, zero once its island is awake
+	supports  []*Actor // what it was resting on that isn't dynamic, when it fell asleep
+
 	frameAcceleration Vec2
+	frameTorque       float64
 }
 
 func (c componentPhysicsImpl) Mass() float64 { return c.mass }
@@ -259,19 +352,112 @@ func (c *componentPhysicsImpl) SetMass(newMass float64) error {
 	return nil
 }
 
+func (c componentPhysicsImpl) InverseMass() float64 {
+	if c.bodyType != BodyDynamic {
+		return 0
+	}
+	return 1 / c.mass
+}
+
+func (c componentPhysicsImpl) BodyType() BodyType             { return c.bodyType }
+func (c *componentPhysicsImpl) SetBodyType(bodyType BodyType) { c.bodyType = bodyType }
+
 func (c componentPhysicsImpl) Velocity() Vec2           { return c.velocity }
-func (c *componentPhysicsImpl) SetVelocity(newVel Vec2) { c.velocity = newVel }
+func (c *componentPhysicsImpl) SetVelocity(newVel Vec2) { c.velocity = newVel; c.WakeUp() }
 
+func (c componentPhysicsImpl) Friction() Vec2             { return c.friction }
 func (c *componentPhysicsImpl) SetFriction(friction Vec2) { c.friction = friction }
+func (c componentPhysicsImpl) Gravity() Vec2              { return c.gravity }
 func (c *componentPhysicsImpl) SetGravity(accel Vec2)     { c.gravity = accel }
 
+func (c componentPhysicsImpl) GravityScale() float64          { return c.gravityScale }
+func (c *componentPhysicsImpl) SetGravityScale(scale float64) { c.gravityScale = scale }
+func (c componentPhysicsImpl) MaxVelocity() float64           { return c.maxVelocity }
+func (c *componentPhysicsImpl) SetMaxVelocity(max float64)    { c.maxVelocity = max }
+
+func (c componentPhysicsImpl) Restitution() float64                 { return c.restitution }
+func (c *componentPhysicsImpl) SetRestitution(restitution float64)  { c.restitution = restitution }
+func (c componentPhysicsImpl) SurfaceFriction() float64             { return c.surfaceFriction }
+func (c *componentPhysicsImpl) SetSurfaceFriction(friction float64) { c.surfaceFriction = friction }
+func (c componentPhysicsImpl) Continuous() bool                     { return c.continuous }
+func (c *componentPhysicsImpl) SetContinuous(continuous bool)       { c.continuous = continuous }
+
+func (c componentPhysicsImpl) AngularVelocity() float64 { return c.angularVelocity }
+func (c *componentPhysicsImpl) SetAngularVelocity(newVel float64) {
+	c.angularVelocity = newVel
+	if newVel != 0 {
+		c.WakeUp()
+	}
+}
+func (c componentPhysicsImpl) AngularDamping() float64            { return c.angularDamping }
+func (c *componentPhysicsImpl) SetAngularDamping(damping float64) { c.angularDamping = damping }
+func (c *componentPhysicsImpl) SetInertia(inertia float64)        { c.inertia = math.Max(inertia, 0) }
+func (c componentPhysicsImpl) FixedRotation() bool                { return c.fixedRotation }
+func (c *componentPhysicsImpl) SetFixedRotation(fixed bool)       { c.fixedRotation = fixed }
+
+// Inertia comes from the collider's shape (about the transform's position, scaled) unless it was set
+func (c componentPhysicsImpl) Inertia() float64 {
+	if c.inertia > 0 || c.boundActor == nil {
+		return c.inertia
+	}
+	colliderComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemCollider)
+	if !present {
+		return 0
+	}
+	collider, ok := colliderComp.(shapedCollider)
+	if !ok {
+		return 0
+	}
+	return collider.unitInertia() * c.mass
+}
+
+func (c componentPhysicsImpl) InverseInertia() float64 {
+	if c.bodyType != BodyDynamic || c.fixedRotation {
+		return 0
+	}
+	if inertia := c.Inertia(); inertia > 0 {
+		return 1 / inertia
+	}
+	return 0
+}
+
+func (c componentPhysicsImpl) Sleeping() bool { return c.sleeping }
+func (c *componentPhysicsImpl) WakeUp()       { c.sleeping, c.sleepTime = false, 0 }
+func (c componentPhysicsImpl) CanSleep() bool { return c.canSleep }
+func (c *componentPhysicsImpl) SetCanSleep(canSleep bool) {
+	c.canSleep = canSleep
+	if !canSleep {
+		c.WakeUp()
+	}
+}
+
 func (c *componentPhysicsImpl) Accelerate(acceleration Vec2) {
+	if acceleration != (Vec2{}) {
+		c.WakeUp()
+	}
 	c.frameAcceleration.Translate(acceleration)
 }
 func (c *componentPhysicsImpl) ApplyForce(force Vec2) {
 	force.MultScalar(1 / c.Mass())
 	c.Accelerate(force)
 }
+func (c *componentPhysicsImpl) ApplyTorque(torque float64) {
+	if torque != 0 {
+		c.WakeUp()
+	}
+	c.frameTorque += torque
+}
+
+// ApplyForceAtPoint pushes the body and spins it around its transform's position
+func (c *componentPhysicsImpl) ApplyForceAtPoint(force, point Vec2) {
+	c.ApplyForce(force)
+	if c.boundActor == nil {
+		return
+	}
+	if transformComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemTransform); present {
+		c.ApplyTorque(point.Sub(transformComp.(ComponentTransform).Position()).Cross(force))
+	}
+}
 
 func NewComponentPhysics() (ComponentPhysics, error) {
 	baseComponent, err := NewComponent(ComponentSystemPhysics, ComponentTypePhysics, "physics")
@@ -279,7 +465,10 @@ func NewComponentPhysics() (ComponentPhysics, error) {
 		return nil, err
 	}
 	return &componentPhysicsImpl{
-		ComponentImpl: *baseComponent.(*ComponentImpl),
-		mass:          1,
+		ComponentImpl:   *baseComponent.(*ComponentImpl),
+		mass:            1,
+		gravityScale:    1,
+		surfaceFriction: 0.3,
+		canSleep:        true,
 	}, nil
 }
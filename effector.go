@@ -0,0 +1,260 @@
+package nagae
+
+import (
+	"math"
+	"sort"
+)
+
+// EffectorKind is how an area effector pushes bodies around
+type EffectorKind uint8
+
+const (
+	EffectorDirectional EffectorKind = iota // the same force on everything, ie wind or conveyor belts
+	EffectorRadial                          // towards or away from the effector's position, ie gravity wells
+	EffectorDrag                            // slows everything down
+	EffectorBuoyancy                        // floats bodies by how much of them is below the top of the area
+)
+
+// Falloff is how a radial effector's strength drops with distance
+type Falloff uint8
+
+const (
+	FalloffNone          Falloff = iota
+	FalloffLinear                // full strength at the centre, nothing at the radius
+	FalloffInverseSquare         // strength / (1 + distance^2)
+)
+
+// AreaEffectorConfig tunes an area effector. only the fields for its kind are used
+type AreaEffectorConfig struct {
+	Kind EffectorKind
+
+	Force Vec2 // directional: turned with the effector's transform
+
+	Strength float64 // radial: positive pushes out, negative pulls in
+	Falloff  Falloff
+	Radius   float64 // radial: how far the force reaches. zero means to the edge of the trigger's bounds
+
+	Drag        float64 // drag and buoyancy: force against velocity, per unit of velocity
+	AngularDrag float64 // drag and buoyancy: torque against spin
+
+	Density float64 // buoyancy: mass per square unit of the fluid
+
+	Layers CollisionLayerMask // which colliders it acts on. zero means every layer
+}
+
+// ComponentAreaEffector pushes on the dynamic bodies overlapping its actor's collider. the collider is made a trigger
+// when the effector inits, and Update fails with ErrEffectorNotTrigger if it's been made solid again since.
+// forces go through ComponentPhysics, so they're mass dependent and keep the bodies awake
+type ComponentAreaEffector interface {
+	Component
+
+	Config() AreaEffectorConfig
+	SetConfig(config AreaEffectorConfig)
+
+	Inside() []*Actor // everything overlapping the trigger, in actor id order
+}
+
+// how many sides rounded shapes get when working out how much of them is under water
+const buoyancySegments = 16
+
+type componentAreaEffectorImpl struct {
+	ComponentImpl
+
+	config AreaEffectorConfig
+	inside map[ActorId]*Actor
+}
+
+func (c componentAreaEffectorImpl) Config() AreaEffectorConfig           { return c.config }
+func (c *componentAreaEffectorImpl) SetConfig(config AreaEffectorConfig) { c.config = config }
+
+func (c componentAreaEffectorImpl) Inside() []*Actor {
+	actors := make([]*Actor, 0, len(c.inside))
+	for _, actor := range c.inside {
+		actors = append(actors, actor)
+	}
+	sort.Slice(actors, func(i, j int) bool { return actors[i].actorId < actors[j].actorId })
+	return actors
+}
+
+func (c *componentAreaEffectorImpl) OnTriggerEnter(other *Actor) error {
+	c.inside[other.actorId] = other
+	return nil
+}
+
+func (c *componentAreaEffectorImpl) OnTriggerExit(other *Actor) error {
+	delete(c.inside, other.actorId)
+	return nil
+}
+
+func (c *componentAreaEffectorImpl) Init() error {
+	if collider, present := c.collider(); present {
+		collider.SetTrigger(true)
+	}
+	return nil
+}
+
+func (c *componentAreaEffectorImpl) collider() (ComponentCollider, bool) {
+	if c.boundActor == nil {
+		return nil, false
+	}
+	colliderComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemCollider)
+	if !present {
+		return nil, false
+	}
+	return colliderComp.(ComponentCollider), true
+}
+
+func (c *componentAreaEffectorImpl) Update(dt float64) error {
+	if collider, present := c.collider(); present && !collider.Trigger() {
+		// a solid collider never reports anything inside, so it would silently do nothing
+		return ErrEffectorNotTrigger
+	}
+	if c.boundActor == nil || len(c.inside) == 0 {
+		return nil
+	}
+	transformComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemTransform)
+	if !present {
+		return nil
+	}
+	transform := transformComp.(ComponentTransform)
+	var area Rect
+	if collider, present := c.collider(); present {
+		if bounds, ok := collider.Bounds(); ok {
+			area = bounds
+		}
+	}
+
+	for _, actor := range c.Inside() {
+		physicsComp, present := actor.GetComponentBySystemType(ComponentSystemPhysics)
+		if !present {
+			continue
+		}
+		physics := physicsComp.(*componentPhysicsImpl)
+		if physics.bodyType != BodyDynamic {
+			continue
+		}
+		colliderComp, present := actor.GetComponentBySystemType(ComponentSystemCollider)
+		if !present {
+			continue
+		}
+		collider, ok := colliderComp.(shapedCollider)
+		if !ok {
+			continue
+		}
+		if c.config.Layers != 0 && colliderComp.(ComponentCollider).Layers()&c.config.Layers == 0 {
+			continue
+		}
+		bodyTransform, present := actor.GetComponentBySystemType(ComponentSystemTransform)
+		if !present {
+			continue
+		}
+		position := bodyTransform.(ComponentTransform).Position()
+
+		switch c.config.Kind {
+		case EffectorDirectional:
+			force := c.config.Force
+			force.Rotate(transform.Rotation())
+			physics.ApplyForce(force)
+		case EffectorRadial:
+			c.applyRadial(physics, position.Sub(transform.Position()), area)
+		case EffectorDrag:
+			c.applyDrag(physics, 1)
+		case EffectorBuoyancy:
+			shape, ok := collider.worldShape()
+			if !ok {
+				continue
+			}
+			c.applyBuoyancy(physics, shape, area.Min.Y)
+		}
+	}
+	return nil
+}
+
+func (c *componentAreaEffectorImpl) applyRadial(physics *componentPhysicsImpl, offset Vec2, area Rect) {
+	radius := c.config.Radius
+	if radius <= 0 {
+		radius = area.Size().Hypot() / 2
+	}
+	distance := offset.Hypot()
+	if distance == 0 || distance > radius {
+		return
+	}
+	strength := c.config.Strength
+	switch c.config.Falloff {
+	case FalloffLinear:
+		strength *= 1 - distance/radius
+	case FalloffInverseSquare:
+		strength /= 1 + distance*distance
+	}
+	physics.ApplyForce(offset.Scaled(strength / distance))
+}
+
+// applyDrag slows a body down. amount scales it, for bodies only partly in the area
+func (c *componentAreaEffectorImpl) applyDrag(physics *componentPhysicsImpl, amount float64) {
+	physics.ApplyForce(physics.velocity.Scaled(-c.config.Drag * amount))
+	physics.ApplyTorque(-c.config.AngularDrag * physics.angularVelocity * amount)
+}
+
+// applyBuoyancy pushes against gravity by the weight of the fluid displaced below the surface,
+// through the middle of the submerged part so floating things turn upright
+func (c *componentAreaEffectorImpl) applyBuoyancy(physics *componentPhysicsImpl, shape convexShape, surface float64) {
+	outline := shape.outline(buoyancySegments)
+	submerged := clipBelow(outline, surface)
+	area, centroid := polygonArea(submerged)
+	if area <= 0 {
+		return
+	}
+	gravity := physics.gravity.Scaled(physics.gravityScale)
+	physics.ApplyForceAtPoint(gravity.Scaled(-c.config.Density*area), centroid)
+	if total, _ := polygonArea(outline); total > 0 {
+		c.applyDrag(physics, math.Min(area/total, 1))
+	}
+}
+
+// clipBelow keeps the part of a convex polygon below a horizontal line (larger Y, as Y points down)
+func clipBelow(polygon []Vec2, surface float64) []Vec2 {
+	clipped := make([]Vec2, 0, len(polygon)+1)
+	for i, p := range polygon {
+		q := polygon[(i+1)%len(polygon)]
+		pIn, qIn := p.Y >= surface, q.Y >= surface
+		if pIn {
+			clipped = append(clipped, p)
+		}
+		if pIn != qIn {
+			t := (surface - p.Y) / (q.Y - p.Y)
+			clipped = append(clipped, p.Add(q.Sub(p).Scaled(t)))
+		}
+	}
+	return clipped
+}
+
+// polygonArea is a polygon's area and centroid, whichever way round it winds
+func polygonArea(polygon []Vec2) (float64, Vec2) {
+	if len(polygon) < 3 {
+		return 0, Vec2{}
+	}
+	var area float64
+	var centroid Vec2
+	for i, p := range polygon {
+		q := polygon[(i+1)%len(polygon)]
+		cross := p.Cross(q)
+		area += cross
+		centroid.Translate(p.Add(q).Scaled(cross))
+	}
+	if area == 0 {
+		return 0, Vec2{}
+	}
+	return math.Abs(area) / 2, centroid.Scaled(1 / (3 * area))
+}
+
+func NewComponentAreaEffector(config AreaEffectorConfig) (ComponentAreaEffector, error) {
+	baseComponent, err := NewComponent(ComponentSystemCustom, ComponentTypeAreaEffector, "area effector")
+	if err != nil {
+		return nil, err
+	}
+	return &componentAreaEffectorImpl{
+		ComponentImpl: *baseComponent.(*ComponentImpl),
+		config:        config,
+		inside:        make(map[ActorId]*Actor),
+	}, nil
+}
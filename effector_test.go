@@ -0,0 +1,40 @@
+package nagae
+
+import "testing"
+
+func TestAreaEffectorNeedsTrigger(t *testing.T) {
+	scene := NewScene("test")
+	area, err := NewComponentAABBCollider(Vec2{4, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	effector, err := NewComponentAreaEffector(AreaEffectorConfig{Kind: EffectorDirectional, Force: Vec2{10, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addTestCollider(t, scene, "wind", Vec2{}, area, BodyStatic)
+	scene.actors["wind"].AddComponent(effector)
+	ball, err := NewComponentCircleCollider(0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transform, physics := addTestCollider(t, scene, "ball", Vec2{}, ball, BodyDynamic)
+	physics.SetGravity(Vec2{})
+	if err := scene.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the collider was left solid, so the effector should have made it a trigger
+	if !area.Trigger() {
+		t.Fatal("effector's collider wasn't made a trigger")
+	}
+	step(t, scene, 1.0/60, 30)
+	if x := transform.Position().X; x <= 0 {
+		t.Errorf("ball inside the wind stayed at x = %v", x)
+	}
+
+	area.SetTrigger(false)
+	if err := scene.Update(1.0 / 60); err != ErrEffectorNotTrigger {
+		t.Errorf("update with a solid effector collider gave %v, want %v", err, ErrEffectorNotTrigger)
+	}
+}
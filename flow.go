@@ -0,0 +1,146 @@
+package nagae
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// FlowGuard decides if a declared transition is allowed to happen right now
+type FlowGuard func(from, to FlowStateId, payload interface{}) bool
+
+// SceneFlow is the graph of how the game moves between scenes (splash -> menu -> level -> results -> menu)
+type SceneFlow struct {
+	states  map[FlowStateId]SceneId
+	edges   map[FlowStateId]map[FlowStateId]FlowGuard
+	current FlowStateId
+}
+
+func NewSceneFlow() *SceneFlow {
+	return &SceneFlow{
+		states: make(map[FlowStateId]SceneId),
+		edges:  make(map[FlowStateId]map[FlowStateId]FlowGuard),
+	}
+}
+
+func (f SceneFlow) Current() FlowStateId { return f.current }
+
+func (f SceneFlow) SceneFor(state FlowStateId) (SceneId, bool) {
+	sceneId, present := f.states[state]
+	return sceneId, present
+}
+
+func (f *SceneFlow) AddState(state FlowStateId, sceneId SceneId) error {
+	if _, present := f.states[state]; present {
+		return ErrFlowStatePresent
+	}
+	f.states[state] = sceneId
+	f.edges[state] = make(map[FlowStateId]FlowGuard)
+	return nil
+}
+
+// AllowTransition declares an edge. guard may be nil
+func (f *SceneFlow) AllowTransition(from, to FlowStateId, guard FlowGuard) error {
+	if _, present := f.states[from]; !present {
+		return ErrFlowStateNotPresent
+	}
+	if _, present := f.states[to]; !present {
+		return ErrFlowStateNotPresent
+	}
+	f.edges[from][to] = guard
+	return nil
+}
+
+// Check tells you if moving from the current state to another would be allowed
+func (f SceneFlow) Check(to FlowStateId, payload interface{}) error {
+	if _, present := f.states[to]; !present {
+		return ErrFlowStateNotPresent
+	}
+	guard, present := f.edges[f.current][to]
+	if !present {
+		return ErrUndeclaredTransition
+	}
+	if guard != nil && !guard(f.current, to, payload) {
+		return ErrTransitionGuarded
+	}
+	return nil
+}
+
+func sortedFlowStates(states map[FlowStateId]SceneId) []FlowStateId {
+	ids := make([]FlowStateId, 0, len(states))
+	for id := range states {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// WriteDOT exports the graph for graphviz. guarded edges are dashed
+func (f SceneFlow) WriteDOT(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "digraph flow {"); err != nil {
+		return err
+	}
+	states := sortedFlowStates(f.states)
+	for _, state := range states {
+		if _, err := fmt.Fprintf(w, "\t%q [label=%q];\n", state, fmt.Sprintf("%s\n(%s)", state, f.states[state])); err != nil {
+			return err
+		}
+	}
+	for _, from := range states {
+		targets := make(map[FlowStateId]SceneId)
+		for to := range f.edges[from] {
+			targets[to] = f.states[to]
+		}
+		for _, to := range sortedFlowStates(targets) {
+			style := ""
+			if f.edges[from][to] != nil {
+				style = " [style=dashed]"
+			}
+			if _, err := fmt.Fprintf(w, "\t%q -> %q%s;\n", from, to, style); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+func (s SceneManager) Flow() *SceneFlow { return s.flow }
+
+// SetFlow attaches a flow graph, starting in the given state. the state's scene isn't switched to
+func (s *SceneManager) SetFlow(flow *SceneFlow, start FlowStateId) error {
+	if _, present := flow.states[start]; !present {
+		return ErrFlowStateNotPresent
+	}
+	flow.current = start
+	s.flow = flow
+	return nil
+}
+
+// GoTo follows a declared flow transition, passing the payload to the target scene's enter hook
+func (s *SceneManager) GoTo(state FlowStateId, payload interface{}, transition SceneTransition) error {
+	if s.flow == nil {
+		return ErrNoFlow
+	}
+	if s.transition != nil {
+		return ErrTransitionActive
+	}
+	if err := s.flow.Check(state, payload); err != nil {
+		return err
+	}
+	sceneId := s.flow.states[state]
+	if _, present := s.scenes[sceneId]; !present {
+		return ErrSceneNotPresent
+	}
+	s.sceneStack = append([]SceneId{sceneId}, s.sceneStack...)
+	queued := len(s.sceneStack)
+	if err := s.transitionWithPayload(transition, payload); err != nil {
+		if len(s.sceneStack) == queued {
+			// refused before switching, so the target is still queued
+			s.sceneStack = s.sceneStack[1:]
+		}
+		return err
+	}
+	s.flow.current = state
+	return nil
+}
@@ -6,8 +6,16 @@ type SceneManager struct {
 	scenes       map[SceneId]*Scene
 	currentScene SceneId
 	sceneStack   []SceneId
+	transition   *activeTransition
+	overlays     []sceneLayer
+	loading      *pendingLoad
+	flow         *SceneFlow
 
-	sharedData map[string]interface{}
+	shared *SharedStore
+
+	actions *ActionMap
+	touch   *TouchInput
+	overlay *VirtualOverlay
 }
 
 func NewSceneManager(startScene *Scene) *SceneManager {
@@ -15,8 +23,12 @@ func NewSceneManager(startScene *Scene) *SceneManager {
 		scenes:       make(map[SceneId]*Scene),
 		currentScene: startScene.sceneId,
 		sceneStack:   make([]SceneId, 0),
+		overlays:     make([]sceneLayer, 0),
+
+		shared: NewSharedStore(),
 
-		sharedData: make(map[string]interface{}),
+		actions: NewActionMap(),
+		touch:   NewTouchInput(),
 	}
 	err := manager.AddScene(startScene)
 	if err != nil {
@@ -26,25 +38,145 @@ func NewSceneManager(startScene *Scene) *SceneManager {
 }
 
 func (s SceneManager) CurrentScene() SceneId   { return s.currentScene }
-func (s SceneManager) Scene(id SceneId) *Scene { return s.scenes[s.currentScene] }
-func (s *SceneManager) Init() error            { return s.scenes[s.currentScene].Init() }
+func (s SceneManager) Scene(id SceneId) *Scene { return s.scenes[id] }
+func (s SceneManager) Actions() *ActionMap     { return s.actions }
+func (s SceneManager) Touch() *TouchInput      { return s.touch }
+
+// SetVirtualOverlay puts on screen controls over every scene. nil removes them
+func (s *SceneManager) SetVirtualOverlay(overlay *VirtualOverlay) { s.overlay = overlay }
+
+func (s *SceneManager) Init() error {
+	if err := s.scenes[s.currentScene].Init(); err != nil {
+		return err
+	}
+	return s.scenes[s.currentScene].enter(nil)
+}
 
-func (s *SceneManager) Transition() error {
+func (s SceneManager) Transitioning() bool { return s.transition != nil }
+
+// Transition instantly switches to the next queued scene
+func (s *SceneManager) Transition() error { return s.TransitionWith(nil) }
+
+// TransitionWith switches to the next queued scene, playing the transition over both of them.
+// the old scene's exit hook runs, then the new scene is initialized and its enter hook runs.
+// the new scene is current (and updating) for the whole transition
+func (s *SceneManager) TransitionWith(transition SceneTransition) error {
+	return s.transitionWithPayload(transition, nil)
+}
+
+func (s *SceneManager) transitionWithPayload(transition SceneTransition, payload interface{}) error {
+	if len(s.sceneStack) == 0 {
+		return ErrSceneStackEmpty
+	}
+	if s.transition != nil {
+		return ErrTransitionActive
+	}
+	from := s.currentScene
+	// the old scene gets to refuse leaving before anything points at the new one
+	if err := s.scenes[from].exit(); err != nil {
+		return err
+	}
 	s.currentScene = s.sceneStack[0]
-	if len(s.sceneStack) == 1 {
-		s.sceneStack = make([]SceneId, 0)
-	} else {
-		s.sceneStack = s.sceneStack[1:]
+	s.sceneStack = s.sceneStack[1:]
+
+	if err := s.scenes[s.currentScene].Init(); err != nil {
+		return err
+	}
+	if err := s.scenes[s.currentScene].enter(payload); err != nil {
+		return err
+	}
+	if transition != nil && transition.Duration() > 0 {
+		s.transition = &activeTransition{
+			transition: transition,
+			from:       from,
+		}
 	}
-	return s.scenes[s.currentScene].Init()
+	return nil
 }
 
 func (s *SceneManager) Update(dt float64) error {
-	return s.scenes[s.currentScene].Update(dt)
+	// on screen controls claim their touches before any gestures are made from them
+	s.touch.Poll(dt)
+	if s.overlay != nil {
+		s.overlay.Update(s.touch)
+	}
+	s.touch.Recognize()
+	s.actions.Update()
+	if err := s.pollLoad(); err != nil {
+		return err
+	}
+	if s.transition != nil {
+		s.transition.elapsed += dt
+		if s.transition.Progress() >= 1 {
+			s.transition = nil
+		}
+	}
+
+	layers := s.layers()
+	inputBlocked := false
+	for i := len(layers) - 1; i >= 0; i-- {
+		s.scenes[layers[i].id].receivesInput = !inputBlocked
+		if !layers[i].flags.Has(LayerInputBelow) {
+			inputBlocked = true
+		}
+	}
+	bottom := s.lowestLayer(layers, LayerUpdateBelow)
+	for _, layer := range layers[bottom:] {
+		if err := s.scenes[layer.id].Update(dt); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *SceneManager) Draw(screen *ebiten.Image) error {
-	return s.scenes[s.currentScene].Draw(screen)
+	if err := s.drawScenes(screen); err != nil {
+		return err
+	}
+	if s.overlay != nil {
+		return s.overlay.Draw(screen)
+	}
+	return nil
+}
+
+func (s *SceneManager) drawScenes(screen *ebiten.Image) error {
+	layers := s.layers()
+	bottom := s.lowestLayer(layers, LayerDrawBelow)
+	if bottom == 0 {
+		if err := s.drawBase(screen); err != nil {
+			return err
+		}
+		bottom = 1
+	}
+	for _, layer := range layers[bottom:] {
+		if err := s.scenes[layer.id].Draw(screen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SceneManager) drawBase(screen *ebiten.Image) error {
+	if s.transition == nil {
+		return s.scenes[s.currentScene].Draw(screen)
+	}
+	t := s.transition
+	if err := t.buffers(screen); err != nil {
+		return err
+	}
+	if err := t.fromImage.Clear(); err != nil {
+		return err
+	}
+	if err := t.toImage.Clear(); err != nil {
+		return err
+	}
+	if err := s.scenes[t.from].Draw(t.fromImage); err != nil {
+		return err
+	}
+	if err := s.scenes[s.currentScene].Draw(t.toImage); err != nil {
+		return err
+	}
+	return t.transition.Draw(screen, t.fromImage, t.toImage, t.Progress())
 }
 
 func (s *SceneManager) PushSceneIdToStack(sceneId SceneId) bool {
@@ -64,14 +196,14 @@ func (s *SceneManager) AddScene(scene *Scene) error {
 	return nil
 }
 
+// Shared is the store for data that lives across scenes
+func (s SceneManager) Shared() *SharedStore { return s.shared }
+
+// GetSharedData reads from the default ("") namespace of the shared store
 func (s SceneManager) GetSharedData(key string) (interface{}, bool) {
-	data, present := s.sharedData[key]
-	if !present {
-		return nil, false
-	}
-	return data, true
+	return s.shared.Namespace("").Get(key)
 }
 
 func (s *SceneManager) PutSharedData(key string, data interface{}) {
-	s.sharedData[key] = data
+	s.shared.Namespace("").Put(key, data)
 }
@@ -0,0 +1,331 @@
+package nagae
+
+import "math"
+
+// convexShape is a convex core (a point, a segment or a polygon) grown outwards by radius.
+// circles are a point + radius, capsules a segment + radius, boxes and polygons have no radius
+type convexShape struct {
+	points []Vec2
+	radius float64
+}
+
+func (c convexShape) center() Vec2 {
+	var sum Vec2
+	for _, p := range c.points {
+		sum.Translate(p)
+	}
+	return sum.Scaled(1 / float64(len(c.points)))
+}
+
+func (c convexShape) bounds() Rect {
+	r := Rect{Min: c.points[0], Max: c.points[0]}
+	for _, p := range c.points[1:] {
+		r = r.Union(Rect{Min: p, Max: p})
+	}
+	return r.Expanded(c.radius)
+}
+
+// edges of the core. a point is one degenerate edge, a segment is one edge
+func (c convexShape) edges() [][2]Vec2 {
+	switch len(c.points) {
+	case 1:
+		return [][2]Vec2{{c.points[0], c.points[0]}}
+	case 2:
+		return [][2]Vec2{{c.points[0], c.points[1]}}
+	}
+	edges := make([][2]Vec2, len(c.points))
+	for i := range c.points {
+		edges[i] = [2]Vec2{c.points[i], c.points[(i+1)%len(c.points)]}
+	}
+	return edges
+}
+
+// axes are the outward normals of the core's edges
+func (c convexShape) axes() []Vec2 {
+	if len(c.points) < 2 {
+		return nil
+	}
+	center := c.center()
+	axes := make([]Vec2, 0, len(c.points))
+	for _, edge := range c.edges() {
+		normal := edge[1].Sub(edge[0]).Perp().Normalized()
+		if normal.Dot(edge[0].Sub(center)) < 0 {
+			normal = normal.Scaled(-1)
+		}
+		axes = append(axes, normal)
+	}
+	return axes
+}
+
+func (c convexShape) project(axis Vec2) (float64, float64) {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, p := range c.points {
+		d := p.Dot(axis)
+		min = math.Min(min, d)
+		max = math.Max(max, d)
+	}
+	return min - c.radius, max + c.radius
+}
+
+// support is the furthest point of the (full, radius included) shape along a direction
+func (c convexShape) support(dir Vec2) Vec2 {
+	best, bestDot := c.points[0], math.Inf(-1)
+	for _, p := range c.points {
+		if d := p.Dot(dir); d > bestDot {
+			best, bestDot = p, d
+		}
+	}
+	return best.Add(dir.Normalized().Scaled(c.radius))
+}
+
+// containsCore checks if a point is inside the core polygon (never true for points and segments)
+func (c convexShape) containsCore(p Vec2) bool {
+	if len(c.points) < 3 {
+		return false
+	}
+	center := c.center()
+	for _, edge := range c.edges() {
+		normal := edge[1].Sub(edge[0]).Perp()
+		if normal.Dot(edge[0].Sub(center)) < 0 {
+			normal = normal.Scaled(-1)
+		}
+		if normal.Dot(p.Sub(edge[0])) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// outline is the full shape as a polygon, with rounded parts cut into segments around each core point
+func (c convexShape) outline(segments int) []Vec2 {
+	if c.radius == 0 {
+		return c.points
+	}
+	points := make([]Vec2, 0, len(c.points)*segments)
+	for _, p := range c.points {
+		for i := 0; i < segments; i++ {
+			angle := 2 * math.Pi * float64(i) / float64(segments)
+			points = append(points, p.Add(Vec2{math.Cos(angle), math.Sin(angle)}.Scaled(c.radius)))
+		}
+	}
+	return convexHull(points)
+}
+
+// unitInertia is the shape's moment of inertia per unit mass about the origin
+func (c convexShape) unitInertia() float64 {
+	center := c.center()
+	switch len(c.points) {
+	case 1:
+		return c.radius*c.radius/2 + center.Dot(center)
+	case 2:
+		// a box between the caps, plus the caps as one circle split over both ends
+		length := c.points[1].Sub(c.points[0]).Hypot()
+		boxArea, circleArea := 2*c.radius*length, math.Pi*c.radius*c.radius
+		boxShare := boxArea / (boxArea + circleArea)
+		box := (length*length + 4*c.radius*c.radius) / 12
+		caps := c.radius*c.radius/2 + length*length/4
+		return boxShare*box + (1-boxShare)*caps + center.Dot(center)
+	}
+	var numerator, denominator float64
+	for _, edge := range c.edges() {
+		a, b := edge[0], edge[1]
+		cross := a.Cross(b)
+		numerator += cross * (a.Dot(a) + a.Dot(b) + b.Dot(b))
+		denominator += cross
+	}
+	if denominator == 0 {
+		return 0
+	}
+	return numerator / (6 * denominator)
+}
+
+// contains checks if a point is inside the full shape
+func (c convexShape) contains(p Vec2) bool {
+	if c.containsCore(p) {
+		return true
+	}
+	for _, edge := range c.edges() {
+		if closestOnSegment(p, edge[0], edge[1]).Sub(p).Hypot() <= c.radius {
+			return true
+		}
+	}
+	return false
+}
+
+func closestOnSegment(p, a, b Vec2) Vec2 {
+	ab := b.Sub(a)
+	lengthSq := ab.Dot(ab)
+	if lengthSq == 0 {
+		return a
+	}
+	t := math.Max(0, math.Min(1, p.Sub(a).Dot(ab)/lengthSq))
+	return a.Add(ab.Scaled(t))
+}
+
+// closestSegments finds the closest pair of points between segments p1-q1 and p2-q2
+func closestSegments(p1, q1, p2, q2 Vec2) (Vec2, Vec2) {
+	d1, d2 := q1.Sub(p1), q2.Sub(p2)
+	if denom := d1.Cross(d2); denom != 0 {
+		r := p2.Sub(p1)
+		s, t := r.Cross(d2)/denom, r.Cross(d1)/denom
+		if s >= 0 && s <= 1 && t >= 0 && t <= 1 {
+			hit := p1.Add(d1.Scaled(s))
+			return hit, hit
+		}
+	}
+	// not crossing, so the closest pair has an endpoint in it
+	bestA, bestB := p1, closestOnSegment(p1, p2, q2)
+	bestDist := bestB.Sub(bestA).Hypot()
+	try := func(a, b Vec2) {
+		if dist := b.Sub(a).Hypot(); dist < bestDist {
+			bestA, bestB, bestDist = a, b, dist
+		}
+	}
+	try(q1, closestOnSegment(q1, p2, q2))
+	try(closestOnSegment(p2, p1, q1), p2)
+	try(closestOnSegment(q2, p1, q1), q2)
+	return bestA, bestB
+}
+
+// coreDistance is the distance between two cores and the closest points on each. zero if they overlap
+func coreDistance(a, b convexShape) (float64, Vec2, Vec2) {
+	for _, p := range a.points {
+		if b.containsCore(p) {
+			return 0, p, p
+		}
+	}
+	for _, p := range b.points {
+		if a.containsCore(p) {
+			return 0, p, p
+		}
+	}
+	bestDist := math.Inf(1)
+	var bestA, bestB Vec2
+	for _, edgeA := range a.edges() {
+		for _, edgeB := range b.edges() {
+			pa, pb := closestSegments(edgeA[0], edgeA[1], edgeB[0], edgeB[1])
+			if dist := pb.Sub(pa).Hypot(); dist < bestDist {
+				bestDist, bestA, bestB = dist, pa, pb
+			}
+		}
+	}
+	return bestDist, bestA, bestB
+}
+
+// ContactManifold describes how two shapes overlap. Normal points from the first shape to the second
+type ContactManifold struct {
+	Normal Vec2
+	Depth  float64
+	Points []Vec2
+}
+
+// Flipped is the same contact seen from the other shape
+func (c ContactManifold) Flipped() ContactManifold {
+	return ContactManifold{Normal: c.Normal.Scaled(-1), Depth: c.Depth, Points: c.Points}
+}
+
+// collideShapes is the narrow phase. returns false if the shapes don't touch
+func collideShapes(a, b convexShape) (ContactManifold, bool) {
+	dist, pa, pb := coreDistance(a, b)
+	if dist > 0 {
+		// cores apart, only the radii can be touching
+		if dist >= a.radius+b.radius {
+			return ContactManifold{}, false
+		}
+		normal := pb.Sub(pa).Scaled(1 / dist)
+		surfaceA := pa.Add(normal.Scaled(a.radius))
+		surfaceB := pb.Sub(normal.Scaled(b.radius))
+		return ContactManifold{
+			Normal: normal,
+			Depth:  a.radius + b.radius - dist,
+			Points: []Vec2{surfaceA.Add(surfaceB).Scaled(0.5)},
+		}, true
+	}
+
+	// cores overlap, find the axis of least penetration
+	axes := append(a.axes(), b.axes()...)
+	if len(axes) == 0 {
+		// two circles sitting exactly on top of each other
+		return ContactManifold{Normal: Vec2{0, -1}, Depth: a.radius + b.radius, Points: []Vec2{a.points[0]}}, true
+	}
+	direction := b.center().Sub(a.center())
+	bestDepth := math.Inf(1)
+	var bestAxis Vec2
+	for _, axis := range axes {
+		minA, maxA := a.project(axis)
+		minB, maxB := b.project(axis)
+		depth := math.Min(maxA, maxB) - math.Max(minA, minB)
+		if depth <= 0 {
+			return ContactManifold{}, false
+		}
+		if depth < bestDepth {
+			bestDepth, bestAxis = depth, axis
+		}
+	}
+	if bestAxis.Dot(direction) < 0 {
+		bestAxis = bestAxis.Scaled(-1)
+	}
+	manifold := ContactManifold{Normal: bestAxis, Depth: bestDepth}
+	if len(a.points) >= 3 && len(b.points) >= 3 && a.radius == 0 && b.radius == 0 {
+		manifold.Points = clipContacts(a, b, bestAxis)
+	}
+	if len(manifold.Points) == 0 {
+		manifold.Points = []Vec2{b.support(bestAxis.Scaled(-1))}
+	}
+	return manifold, true
+}
+
+// bestEdge is the edge of a polygon facing most along the normal
+func bestEdge(c convexShape, normal Vec2) [2]Vec2 {
+	edges, axes := c.edges(), c.axes()
+	best, bestDot := 0, math.Inf(-1)
+	for i, axis := range axes {
+		if d := axis.Dot(normal); d > bestDot {
+			best, bestDot = i, d
+		}
+	}
+	return edges[best]
+}
+
+func clipSegment(v1, v2, dir Vec2, offset float64) []Vec2 {
+	clipped := make([]Vec2, 0, 2)
+	d1, d2 := dir.Dot(v1)-offset, dir.Dot(v2)-offset
+	if d1 >= 0 {
+		clipped = append(clipped, v1)
+	}
+	if d2 >= 0 {
+		clipped = append(clipped, v2)
+	}
+	if d1*d2 < 0 {
+		clipped = append(clipped, v1.Add(v2.Sub(v1).Scaled(d1/(d1-d2))))
+	}
+	return clipped
+}
+
+// clipContacts finds up to two contact points between polygons by clipping the incident edge to the reference edge
+func clipContacts(a, b convexShape, normal Vec2) []Vec2 {
+	edgeA, edgeB := bestEdge(a, normal), bestEdge(b, normal.Scaled(-1))
+	ref, inc := edgeA, edgeB
+	refNormal := normal
+	if math.Abs(edgeB[1].Sub(edgeB[0]).Normalized().Dot(normal)) < math.Abs(edgeA[1].Sub(edgeA[0]).Normalized().Dot(normal)) {
+		ref, inc = edgeB, edgeA
+		refNormal = normal.Scaled(-1)
+	}
+	refDir := ref[1].Sub(ref[0]).Normalized()
+	points := clipSegment(inc[0], inc[1], refDir, refDir.Dot(ref[0]))
+	if len(points) < 2 {
+		return nil
+	}
+	points = clipSegment(points[0], points[1], refDir.Scaled(-1), -refDir.Dot(ref[1]))
+	if len(points) < 2 {
+		return nil
+	}
+	contacts := make([]Vec2, 0, 2)
+	refOffset := refNormal.Dot(ref[0])
+	for _, p := range points {
+		if refNormal.Dot(p)-refOffset <= 0 {
+			contacts = append(contacts, p)
+		}
+	}
+	return contacts
+}
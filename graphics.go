@@ -150,15 +150,23 @@ func (a *animatedSpriteImpl) SetSecondsToRun(seconds float64) {
 	a.ticksPerFrame = int(ticksPerFrame)
 }
 
+// PixelsPerUnit is how many screen pixels one world unit takes up
+const PixelsPerUnit = 100
+
+func ScreenToWorld(x, y float64) Vec2 { return Vec2{x / PixelsPerUnit, y / PixelsPerUnit} }
+func WorldToScreen(v Vec2) (float64, float64) {
+	return v.X * PixelsPerUnit, v.Y * PixelsPerUnit
+}
+
 type DrawCall func(screen *ebiten.Image) error
 
 func GetDrawCall(image *ebiten.Image, x, y, w, h, angle float64) DrawCall {
 	drawOptions := ebiten.DrawImageOptions{}
 	drawOptions.GeoM.Reset()
 	imageW, imageH := image.Size()
-	drawOptions.GeoM.Scale(w/float64(imageW)*100, h/float64(imageH)*100)
+	drawOptions.GeoM.Scale(w/float64(imageW)*PixelsPerUnit, h/float64(imageH)*PixelsPerUnit)
 	drawOptions.GeoM.Rotate(angle)
-	drawOptions.GeoM.Translate(x*100, y*100)
+	drawOptions.GeoM.Translate(x*PixelsPerUnit, y*PixelsPerUnit)
 	return func(screen *ebiten.Image) error {
 		return screen.DrawImage(image, &drawOptions)
 	}
@@ -0,0 +1,402 @@
+package nagae
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// InputKind is what physical device a binding refers to
+type InputKind uint8
+
+const (
+	InputKindKey InputKind = iota
+	InputKindMouse
+	InputKindGamepad
+)
+
+// InputBinding is a single physical input (a key, a mouse button, a gamepad button)
+type InputBinding struct {
+	Kind InputKind
+	Code int
+}
+
+func KeyBinding(key ebiten.Key) InputBinding {
+	return InputBinding{Kind: InputKindKey, Code: int(key)}
+}
+
+func MouseBinding(button ebiten.MouseButton) InputBinding {
+	return InputBinding{Kind: InputKindMouse, Code: int(button)}
+}
+
+func GamepadBinding(button ebiten.GamepadButton) InputBinding {
+	return InputBinding{Kind: InputKindGamepad, Code: int(button)}
+}
+
+var mouseButtonNames = map[ebiten.MouseButton]string{
+	ebiten.MouseButtonLeft:   "left",
+	ebiten.MouseButtonRight:  "right",
+	ebiten.MouseButtonMiddle: "middle",
+}
+
+// String gives the human editable form of a binding, ie "key:Space", "mouse:left", "gamepad:3"
+func (b InputBinding) String() string {
+	switch b.Kind {
+	case InputKindKey:
+		return "key:" + ebiten.Key(b.Code).String()
+	case InputKindMouse:
+		return "mouse:" + mouseButtonNames[ebiten.MouseButton(b.Code)]
+	case InputKindGamepad:
+		return "gamepad:" + strconv.Itoa(b.Code)
+	}
+	return "unknown"
+}
+
+func ParseInputBinding(s string) (InputBinding, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return InputBinding{}, fmt.Errorf("binding %q is not of the form kind:name", s)
+	}
+	kind, name := strings.ToLower(strings.TrimSpace(parts[0])), strings.TrimSpace(parts[1])
+	switch kind {
+	case "key":
+		for key := ebiten.Key(0); key <= ebiten.KeyMax; key++ {
+			if strings.EqualFold(key.String(), name) {
+				return KeyBinding(key), nil
+			}
+		}
+	case "mouse":
+		for button, buttonName := range mouseButtonNames {
+			if strings.EqualFold(buttonName, name) {
+				return MouseBinding(button), nil
+			}
+		}
+	case "gamepad":
+		button, err := strconv.Atoi(name)
+		if err == nil && button >= 0 && button <= int(ebiten.GamepadButtonMax) {
+			return GamepadBinding(ebiten.GamepadButton(button)), nil
+		}
+	}
+	return InputBinding{}, fmt.Errorf("unknown binding %q", s)
+}
+
+func (b InputBinding) MarshalText() ([]byte, error) { return []byte(b.String()), nil }
+func (b *InputBinding) UnmarshalText(text []byte) error {
+	parsed, err := ParseInputBinding(string(text))
+	if err != nil {
+		return err
+	}
+	*b = parsed
+	return nil
+}
+
+// allBindings lists every physical input we know how to listen for
+func allBindings() []InputBinding {
+	bindings := make([]InputBinding, 0)
+	for key := ebiten.Key(0); key <= ebiten.KeyMax; key++ {
+		bindings = append(bindings, KeyBinding(key))
+	}
+	for _, button := range []ebiten.MouseButton{ebiten.MouseButtonLeft, ebiten.MouseButtonRight, ebiten.MouseButtonMiddle} {
+		bindings = append(bindings, MouseBinding(button))
+	}
+	for button := ebiten.GamepadButton0; button <= ebiten.GamepadButtonMax; button++ {
+		bindings = append(bindings, GamepadBinding(button))
+	}
+	return bindings
+}
+
+// InputSource is where physical input state comes from. swap it out to feed synthetic input
+type InputSource interface {
+	IsPressed(binding InputBinding) bool
+}
+
+type ebitenInputSource struct{}
+
+func (e ebitenInputSource) IsPressed(binding InputBinding) bool {
+	switch binding.Kind {
+	case InputKindKey:
+		return ebiten.IsKeyPressed(ebiten.Key(binding.Code))
+	case InputKindMouse:
+		return ebiten.IsMouseButtonPressed(ebiten.MouseButton(binding.Code))
+	case InputKindGamepad:
+		for _, id := range ebiten.GamepadIDs() {
+			if ebiten.IsGamepadButtonPressed(id, ebiten.GamepadButton(binding.Code)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func NewEbitenInputSource() InputSource { return ebitenInputSource{} }
+
+// RebindCallback is called once a listened for rebind catches an input.
+// conflicts are the other actions that share the new binding
+type RebindCallback func(action ActionId, binding InputBinding, conflicts []ActionId)
+
+type actionState struct {
+	pressed, wasPressed bool
+}
+
+// ActionMap maps named actions onto physical inputs
+type ActionMap struct {
+	source InputSource
+
+	defaults map[ActionId][]InputBinding
+	bindings map[ActionId][]InputBinding
+	state    map[ActionId]*actionState
+	virtual  map[ActionId]bool
+
+	listening      bool
+	listenAction   ActionId
+	listenCallback RebindCallback
+	listenHeld     map[InputBinding]bool
+	captured       map[InputBinding]bool // just captured by a rebind, ignored until released
+}
+
+func NewActionMap() *ActionMap {
+	return &ActionMap{
+		source:   NewEbitenInputSource(),
+		defaults: make(map[ActionId][]InputBinding),
+		bindings: make(map[ActionId][]InputBinding),
+		state:    make(map[ActionId]*actionState),
+		virtual:  make(map[ActionId]bool),
+		captured: make(map[InputBinding]bool),
+	}
+}
+
+func (a ActionMap) Source() InputSource           { return a.source }
+func (a *ActionMap) SetSource(source InputSource) { a.source = source }
+func (a ActionMap) Listening() bool               { return a.listening }
+func (a ActionMap) Bindings(action ActionId) []InputBinding {
+	return append([]InputBinding(nil), a.bindings[action]...)
+}
+
+// DefineAction registers an action and the bindings it resets to
+func (a *ActionMap) DefineAction(action ActionId, defaults ...InputBinding) {
+	a.defaults[action] = append([]InputBinding(nil), defaults...)
+	a.bindings[action] = append([]InputBinding(nil), defaults...)
+	a.state[action] = &actionState{}
+}
+
+func (a ActionMap) Actions() []ActionId {
+	actions := make([]ActionId, 0, len(a.defaults))
+	for action := range a.defaults {
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i] < actions[j] })
+	return actions
+}
+
+// Bind replaces all the bindings on an action
+func (a *ActionMap) Bind(action ActionId, bindings ...InputBinding) error {
+	if _, present := a.defaults[action]; !present {
+		return ErrActionNotPresent
+	}
+	a.bindings[action] = append([]InputBinding(nil), bindings...)
+	return nil
+}
+
+func (a *ActionMap) AddBinding(action ActionId, binding InputBinding) error {
+	if _, present := a.defaults[action]; !present {
+		return ErrActionNotPresent
+	}
+	for _, existing := range a.bindings[action] {
+		if existing == binding {
+			return nil
+		}
+	}
+	a.bindings[action] = append(a.bindings[action], binding)
+	return nil
+}
+
+func (a *ActionMap) Unbind(action ActionId, binding InputBinding) error {
+	bindings, present := a.bindings[action]
+	if !present {
+		return ErrActionNotPresent
+	}
+	kept := make([]InputBinding, 0, len(bindings))
+	for _, existing := range bindings {
+		if existing != binding {
+			kept = append(kept, existing)
+		}
+	}
+	a.bindings[action] = kept
+	return nil
+}
+
+// Conflicts lists every action (other than except) that the binding is already on
+func (a ActionMap) Conflicts(binding InputBinding, except ActionId) []ActionId {
+	conflicts := make([]ActionId, 0)
+	for _, action := range a.Actions() {
+		if action == except {
+			continue
+		}
+		for _, existing := range a.bindings[action] {
+			if existing == binding {
+				conflicts = append(conflicts, action)
+				break
+			}
+		}
+	}
+	return conflicts
+}
+
+func (a *ActionMap) ResetToDefaults() {
+	for action, defaults := range a.defaults {
+		a.bindings[action] = append([]InputBinding(nil), defaults...)
+	}
+}
+
+func (a *ActionMap) ResetAction(action ActionId) error {
+	defaults, present := a.defaults[action]
+	if !present {
+		return ErrActionNotPresent
+	}
+	a.bindings[action] = append([]InputBinding(nil), defaults...)
+	return nil
+}
+
+// ListenForRebind waits for the next physical input and binds it to the action (replacing what was there).
+// inputs held down when listening starts are ignored until they're released
+func (a *ActionMap) ListenForRebind(action ActionId, callback RebindCallback) error {
+	if _, present := a.defaults[action]; !present {
+		return ErrActionNotPresent
+	}
+	a.listening = true
+	a.listenAction = action
+	a.listenCallback = callback
+	a.listenHeld = make(map[InputBinding]bool)
+	for _, binding := range allBindings() {
+		if a.source.IsPressed(binding) {
+			a.listenHeld[binding] = true
+		}
+	}
+	return nil
+}
+
+func (a *ActionMap) CancelRebind() {
+	a.listening = false
+	a.listenCallback = nil
+	a.listenHeld = nil
+}
+
+func (a ActionMap) Pressed(action ActionId) bool {
+	state, present := a.state[action]
+	return present && state.pressed
+}
+
+func (a ActionMap) JustPressed(action ActionId) bool {
+	state, present := a.state[action]
+	return present && state.pressed && !state.wasPressed
+}
+
+func (a ActionMap) JustReleased(action ActionId) bool {
+	state, present := a.state[action]
+	return present && !state.pressed && state.wasPressed
+}
+
+// SetVirtualPressed holds an action down from something that isn't a physical input (on screen controls)
+func (a *ActionMap) SetVirtualPressed(action ActionId, pressed bool) {
+	if pressed {
+		a.virtual[action] = true
+	} else {
+		delete(a.virtual, action)
+	}
+}
+
+// Update polls the input source. call once per tick (SceneManager does this for its own map)
+func (a *ActionMap) Update() {
+	capturing := a.listening
+	if a.listening {
+		a.updateListen()
+	}
+	for binding := range a.captured {
+		if !a.source.IsPressed(binding) {
+			delete(a.captured, binding)
+		}
+	}
+	for action, state := range a.state {
+		state.wasPressed = state.pressed
+		state.pressed = false
+		if capturing {
+			// don't leak the input being captured into gameplay, even on the frame it's captured
+			continue
+		}
+		if a.virtual[action] {
+			state.pressed = true
+			continue
+		}
+		for _, binding := range a.bindings[action] {
+			if !a.captured[binding] && a.source.IsPressed(binding) {
+				state.pressed = true
+				break
+			}
+		}
+	}
+}
+
+func (a *ActionMap) updateListen() {
+	for _, binding := range allBindings() {
+		pressed := a.source.IsPressed(binding)
+		if a.listenHeld[binding] {
+			if !pressed {
+				delete(a.listenHeld, binding)
+			}
+			continue
+		}
+		if !pressed {
+			continue
+		}
+		action, callback := a.listenAction, a.listenCallback
+		a.CancelRebind()
+		a.bindings[action] = []InputBinding{binding}
+		a.captured[binding] = true
+		if callback != nil {
+			callback(action, binding, a.Conflicts(binding, action))
+		}
+		return
+	}
+}
+
+// BindingsConfigPath is where bindings for an app live in the user's config directory
+func BindingsConfigPath(appName string) (string, error) {
+	return UserConfigPath(appName, "bindings.json")
+}
+
+func (a ActionMap) SaveBindings(path string) error {
+	data, err := json.MarshalIndent(a.bindings, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// LoadBindings reads saved bindings. actions in the file that aren't defined are ignored,
+// and defined actions missing from the file keep their current bindings
+func (a *ActionMap) LoadBindings(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	loaded := make(map[ActionId][]InputBinding)
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return err
+	}
+	for action, bindings := range loaded {
+		if _, present := a.defaults[action]; present {
+			a.bindings[action] = bindings
+		}
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package nagae
+
+import "math"
+
+// SequenceStep is a set of actions that have to be down together (ie "forward" + "punch").
+// at least one of them has to be pressed for the step, the rest can still be held from earlier steps
+// (down, down+forward, forward+punch rolls through without letting go)
+type SequenceStep []ActionId
+
+// InputSequence is a motion/combo to watch for. times are in seconds (a frame at 60tps is 1/60)
+type InputSequence struct {
+	Id    SequenceId
+	Steps []SequenceStep
+
+	StepWindow  float64 // max time between one step and the next
+	ChordWindow float64 // max spread between presses in the same step
+	TotalWindow float64 // max time for the whole sequence. 0 means no limit
+}
+
+// SequenceListener is implemented by components that want to hear about completed sequences on their actor
+type SequenceListener interface {
+	OnSequence(id SequenceId) error
+}
+
+type inputPress struct {
+	action   ActionId
+	time     float64
+	released float64 // when it was let go, negative while it's still held
+	consumed bool
+}
+
+// ComponentInputBuffer keeps a timestamped history of action presses for one player.
+// it's used for buffering (jump pressed just before landing) and for matching sequences
+type ComponentInputBuffer interface {
+	Component
+
+	Actions() *ActionMap
+	SetActions(actions *ActionMap)
+
+	AddSequence(sequence InputSequence)
+	RemoveSequence(id SequenceId)
+
+	SetHistoryLength(seconds float64)
+	Buffered(action ActionId, window float64) bool
+	Consume(action ActionId, window float64) bool
+	Clear()
+}
+
+type componentInputBufferImpl struct {
+	ComponentImpl
+
+	actions       *ActionMap
+	time          float64
+	historyLength float64
+	history       []inputPress
+
+	sequences   []InputSequence
+	lastMatched map[SequenceId]float64
+}
+
+func (c componentInputBufferImpl) Actions() *ActionMap            { return c.actions }
+func (c *componentInputBufferImpl) SetActions(actions *ActionMap) { c.actions = actions }
+func (c *componentInputBufferImpl) SetHistoryLength(seconds float64) {
+	c.historyLength = seconds
+}
+func (c *componentInputBufferImpl) Clear() { c.history = c.history[:0] }
+
+func (c *componentInputBufferImpl) AddSequence(sequence InputSequence) {
+	c.RemoveSequence(sequence.Id)
+	c.sequences = append(c.sequences, sequence)
+}
+
+func (c *componentInputBufferImpl) RemoveSequence(id SequenceId) {
+	for i, sequence := range c.sequences {
+		if sequence.Id == id {
+			c.sequences = append(c.sequences[:i], c.sequences[i+1:]...)
+			break
+		}
+	}
+	delete(c.lastMatched, id)
+}
+
+// Buffered checks if the action was pressed within the last window seconds and hasn't been consumed
+func (c componentInputBufferImpl) Buffered(action ActionId, window float64) bool {
+	for i := len(c.history) - 1; i >= 0; i-- {
+		press := c.history[i]
+		if c.time-press.time > window {
+			break
+		}
+		if press.action == action && !press.consumed {
+			return true
+		}
+	}
+	return false
+}
+
+// Consume is Buffered, but marks the press as used so it only triggers once
+func (c *componentInputBufferImpl) Consume(action ActionId, window float64) bool {
+	for i := len(c.history) - 1; i >= 0; i-- {
+		press := &c.history[i]
+		if c.time-press.time > window {
+			break
+		}
+		if press.action == action && !press.consumed {
+			press.consumed = true
+			return true
+		}
+	}
+	return false
+}
+
+func (c *componentInputBufferImpl) Update(dt float64) error {
+	c.time += dt
+	if c.actions == nil {
+		return nil
+	}
+	if c.boundActor != nil && c.boundActor.parentScene != nil && !c.boundActor.parentScene.ReceivesInput() {
+		return nil
+	}
+
+	// drop everything that's too old to matter, except presses still being held
+	kept := c.history[:0]
+	for _, press := range c.history {
+		if press.released < 0 && !c.actions.Pressed(press.action) {
+			press.released = c.time
+		}
+		if c.time-press.time <= c.historyLength || press.released < 0 {
+			kept = append(kept, press)
+		}
+	}
+	c.history = kept
+
+	pressedNow := false
+	for _, action := range c.actions.Actions() {
+		if c.actions.JustPressed(action) {
+			c.history = append(c.history, inputPress{action: action, time: c.time, released: -1})
+			pressedNow = true
+		}
+	}
+	if !pressedNow {
+		return nil
+	}
+
+	for _, sequence := range c.sequences {
+		start, matched := c.matchSequence(sequence)
+		if !matched {
+			continue
+		}
+		if last, present := c.lastMatched[sequence.Id]; present && start <= last {
+			// these presses already completed this sequence once
+			continue
+		}
+		c.lastMatched[sequence.Id] = c.time
+		if err := c.emitSequence(sequence.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchSequence walks backwards through the history, matching the last step against this frame's presses.
+// returns when the sequence started
+func (c componentInputBufferImpl) matchSequence(sequence InputSequence) (float64, bool) {
+	if len(sequence.Steps) == 0 {
+		return 0, false
+	}
+	start, ok := c.matchStep(sequence, len(sequence.Steps)-1, c.time)
+	if !ok || (sequence.TotalWindow > 0 && c.time-start > sequence.TotalWindow) {
+		return 0, false
+	}
+	return start, true
+}
+
+// matchStep matches a step and everything before it, from before cursor (or at it, for the last step).
+// the step is anchored on the latest press of one of its actions. the others have to be pressed within the chord
+// window of it, or be held down at that moment. a held action pressed just before the anchor could be part of this
+// step's chord or left over from an earlier step, so both are tried
+func (c componentInputBufferImpl) matchStep(sequence InputSequence, i int, cursor float64) (float64, bool) {
+	last := i == len(sequence.Steps)-1
+	anchor, found := -1.0, false
+	for _, action := range sequence.Steps[i] {
+		if pressTime, ok := c.latestPress(action, cursor, last); ok && pressTime > anchor {
+			anchor, found = pressTime, true
+		}
+	}
+	if !found {
+		return 0, false
+	}
+	if last && anchor != c.time {
+		// the sequence has to finish on this frame's input
+		return 0, false
+	}
+	if !last && cursor-anchor > sequence.StepWindow {
+		return 0, false
+	}
+	chord, fresh := anchor, anchor // earliest press counting held actions' presses, and not counting them
+	for _, action := range sequence.Steps[i] {
+		pressTime, pressed := c.latestPress(action, anchor, true)
+		pressed = pressed && anchor-pressTime <= sequence.ChordWindow
+		held := c.heldAt(action, anchor)
+		switch {
+		case pressed && held:
+			chord = math.Min(chord, pressTime)
+		case pressed:
+			chord, fresh = math.Min(chord, pressTime), math.Min(fresh, pressTime)
+		case !held:
+			return 0, false
+		}
+	}
+	if i == 0 {
+		return chord, true
+	}
+	if start, ok := c.matchStep(sequence, i-1, chord); ok {
+		return start, true
+	}
+	if fresh != chord {
+		return c.matchStep(sequence, i-1, fresh)
+	}
+	return 0, false
+}
+
+// latestPress finds the most recent press of an action before (or at, if inclusive) a time
+func (c componentInputBufferImpl) latestPress(action ActionId, before float64, inclusive bool) (float64, bool) {
+	for i := len(c.history) - 1; i >= 0; i-- {
+		press := c.history[i]
+		if press.action != action {
+			continue
+		}
+		if press.time < before || (inclusive && press.time == before) {
+			return press.time, true
+		}
+	}
+	return 0, false
+}
+
+// heldAt is whether an action was down at a time, pressed at or before it and not let go until after
+func (c componentInputBufferImpl) heldAt(action ActionId, time float64) bool {
+	for i := len(c.history) - 1; i >= 0; i-- {
+		press := c.history[i]
+		if press.action == action && press.time <= time {
+			return press.released < 0 || press.released > time
+		}
+	}
+	return false
+}
+
+func (c *componentInputBufferImpl) emitSequence(id SequenceId) error {
+	if c.boundActor == nil {
+		return nil
+	}
+	return c.boundActor.eachComponent(func(component Component) error {
+		if listener, ok := component.(SequenceListener); ok {
+			return listener.OnSequence(id)
+		}
+		return nil
+	})
+}
+
+func NewComponentInputBuffer(actions *ActionMap) (ComponentInputBuffer, error) {
+	baseComponent, err := NewComponent(ComponentSystemCustom, ComponentTypeInputBuffer, "input buffer")
+	if err != nil {
+		return nil, err
+	}
+	return &componentInputBufferImpl{
+		ComponentImpl: *baseComponent.(*ComponentImpl),
+		actions:       actions,
+		historyLength: 1,
+		history:       make([]inputPress, 0),
+		sequences:     make([]InputSequence, 0),
+		lastMatched:   make(map[SequenceId]float64),
+	}, nil
+}
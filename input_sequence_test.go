@@ -0,0 +1,91 @@
+package nagae
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+type fakeInputSource map[InputBinding]bool
+
+func (f fakeInputSource) IsPressed(binding InputBinding) bool { return f[binding] }
+
+// playSequence runs frames of held actions through an input buffer, giving whether the sequence fired on the last one
+func playSequence(t *testing.T, sequence InputSequence, frames [][]ActionId) bool {
+	t.Helper()
+	bindings := map[ActionId]InputBinding{
+		"down":    KeyBinding(ebiten.KeyS),
+		"forward": KeyBinding(ebiten.KeyD),
+		"punch":   KeyBinding(ebiten.KeyJ),
+	}
+	source := fakeInputSource{}
+	actions := NewActionMap()
+	actions.SetSource(source)
+	for action, binding := range bindings {
+		actions.DefineAction(action, binding)
+	}
+	buffer, err := NewComponentInputBuffer(actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buffer.AddSequence(sequence)
+	impl := buffer.(*componentInputBufferImpl)
+	fired := false
+	for _, held := range frames {
+		for binding := range source {
+			delete(source, binding)
+		}
+		for _, action := range held {
+			source[bindings[action]] = true
+		}
+		actions.Update()
+		before := len(impl.lastMatched)
+		if err := buffer.Update(1.0 / 60); err != nil {
+			t.Fatal(err)
+		}
+		fired = len(impl.lastMatched) > before
+	}
+	return fired
+}
+
+func TestSequenceMotionWithHeldDirections(t *testing.T) {
+	quarterCircle := InputSequence{
+		Id:          "fireball",
+		Steps:       []SequenceStep{{"down"}, {"down", "forward"}, {"forward", "punch"}},
+		StepWindow:  0.2,
+		ChordWindow: 0.05,
+	}
+	repeat := func(n int, held ...ActionId) [][]ActionId {
+		frames := make([][]ActionId, n)
+		for i := range frames {
+			frames[i] = held
+		}
+		return frames
+	}
+	join := func(parts ...[][]ActionId) [][]ActionId {
+		frames := make([][]ActionId, 0)
+		for _, part := range parts {
+			frames = append(frames, part...)
+		}
+		return frames
+	}
+
+	cases := []struct {
+		name   string
+		frames [][]ActionId
+		want   bool
+	}{
+		{"rolled without letting go", join(repeat(3, "down"), repeat(3, "down", "forward"), repeat(2, "forward"), repeat(1, "forward", "punch")), true},
+		{"each step pressed fresh", join(repeat(2, "down"), repeat(1), repeat(2, "down", "forward"), repeat(1), repeat(1, "forward", "punch")), true},
+		{"forward let go before punch", join(repeat(3, "down"), repeat(3, "down", "forward"), repeat(2), repeat(1, "punch")), false},
+		{"no down first", join(repeat(3, "forward"), repeat(1, "forward", "punch")), false},
+		{"too slow between steps", join(repeat(3, "down"), repeat(30, "down", "forward"), repeat(1, "forward", "punch")), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := playSequence(t, quarterCircle, c.frames); got != c.want {
+				t.Errorf("fired %v, want %v", got, c.want)
+			}
+		})
+	}
+}
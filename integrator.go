@@ -0,0 +1,73 @@
+package nagae
+
+// Integrator is how the physics system steps bodies forward in time
+type Integrator uint8
+
+const (
+	IntegratorSemiImplicitEuler Integrator = iota // velocity first, then position with the new velocity
+	IntegratorVerlet                              // velocity verlet
+	IntegratorRK4                                 // classic fourth order runge kutta
+)
+
+// accelerationFunc gives a body's acceleration for a velocity (drag depends on it)
+type accelerationFunc func(velocity Vec2) Vec2
+
+// bodyAcceleration is forces + scaled gravity - per axis linear drag
+func bodyAcceleration(body *componentPhysicsImpl) accelerationFunc {
+	constant := body.frameAcceleration.Add(body.gravity.Scaled(body.gravityScale))
+	drag := body.friction
+	return func(velocity Vec2) Vec2 {
+		return Vec2{constant.X - drag.X*velocity.X, constant.Y - drag.Y*velocity.Y}
+	}
+}
+
+// integrate gives the change in position and the new velocity after dt
+func integrate(integrator Integrator, velocity Vec2, accel accelerationFunc, dt float64) (Vec2, Vec2) {
+	switch integrator {
+	case IntegratorVerlet:
+		a0 := accel(velocity)
+		delta := velocity.Scaled(dt).Add(a0.Scaled(dt * dt / 2))
+		a1 := accel(velocity.Add(a0.Scaled(dt)))
+		return delta, velocity.Add(a0.Add(a1).Scaled(dt / 2))
+	case IntegratorRK4:
+		k1v := accel(velocity)
+		k1x := velocity
+		k2x := velocity.Add(k1v.Scaled(dt / 2))
+		k2v := accel(k2x)
+		k3x := velocity.Add(k2v.Scaled(dt / 2))
+		k3v := accel(k3x)
+		k4x := velocity.Add(k3v.Scaled(dt))
+		k4v := accel(k4x)
+		delta := k1x.Add(k2x.Scaled(2)).Add(k3x.Scaled(2)).Add(k4x).Scaled(dt / 6)
+		return delta, velocity.Add(k1v.Add(k2v.Scaled(2)).Add(k3v.Scaled(2)).Add(k4v).Scaled(dt / 6))
+	}
+	newVelocity := velocity.Add(accel(velocity).Scaled(dt))
+	return newVelocity.Scaled(dt), newVelocity
+}
+
+// clampVelocity keeps a velocity's length under max. a max of zero means no limit
+func clampVelocity(velocity Vec2, max float64) Vec2 {
+	if max <= 0 {
+		return velocity
+	}
+	if speed := velocity.Hypot(); speed > max {
+		return velocity.Scaled(max / speed)
+	}
+	return velocity
+}
+
+// integrateRotation steps a dynamic body's spin, treating angular velocity as a one dimensional velocity
+func integrateRotation(integrator Integrator, body *componentPhysicsImpl, transform *componentTransformImpl, dt float64) {
+	torque := body.frameTorque
+	body.frameTorque = 0
+	if body.fixedRotation {
+		body.angularVelocity = 0
+		return
+	}
+	alpha, damping := torque*body.InverseInertia(), body.angularDamping
+	delta, velocity := integrate(integrator, Vec2{body.angularVelocity, 0}, func(velocity Vec2) Vec2 {
+		return Vec2{alpha - damping*velocity.X, 0}
+	}, dt)
+	body.angularVelocity = velocity.X
+	transform.rotation += delta.X
+}
@@ -0,0 +1,154 @@
+package nagae
+
+import (
+	"math"
+	"testing"
+)
+
+var integrators = []struct {
+	name       string
+	integrator Integrator
+}{
+	{"semi-implicit euler", IntegratorSemiImplicitEuler},
+	{"verlet", IntegratorVerlet},
+	{"rk4", IntegratorRK4},
+}
+
+// newTestBody puts a dynamic body with no collider into a fresh scene
+func newTestBody(t *testing.T, integrator Integrator, position, velocity Vec2) (*Scene, ComponentTransform, ComponentPhysics) {
+	t.Helper()
+	scene := NewScene("test")
+	scene.Physics().SetIntegrator(integrator)
+	actor := NewActor("body")
+	transform, err := NewComponentTransform()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transform.SetPosition(position)
+	physics, err := NewComponentPhysics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	physics.SetVelocity(velocity)
+	physics.SetCanSleep(false)
+	actor.AddComponent(transform)
+	actor.AddComponent(physics)
+	scene.AddActor(actor)
+	if err := scene.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return scene, transform, physics
+}
+
+func step(t *testing.T, scene *Scene, dt float64, steps int) {
+	t.Helper()
+	for i := 0; i < steps; i++ {
+		if err := scene.Update(dt); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIntegratorFreeFall(t *testing.T) {
+	const dt, steps = 1.0 / 60, 120
+	gravity, v0 := Vec2{0, 9.8}, Vec2{3, -5}
+	duration := dt * steps
+	wantPosition := v0.Scaled(duration).Add(gravity.Scaled(duration * duration / 2))
+	wantVelocity := v0.Add(gravity.Scaled(duration))
+
+	for _, c := range integrators {
+		t.Run(c.name, func(t *testing.T) {
+			scene, transform, physics := newTestBody(t, c.integrator, Vec2{}, v0)
+			physics.SetGravity(gravity)
+			step(t, scene, dt, steps)
+
+			// semi-implicit euler moves with the end of step velocity, so it's ahead by g*t*dt/2. the others are exact
+			tolerance := 1e-9
+			if c.integrator == IntegratorSemiImplicitEuler {
+				tolerance += gravity.Hypot() * duration * dt / 2
+			}
+			if err := transform.Position().Sub(wantPosition).Hypot(); err > tolerance {
+				t.Errorf("position %v, want %v (off by %g, tolerance %g)", transform.Position(), wantPosition, err, tolerance)
+			}
+			if err := physics.Velocity().Sub(wantVelocity).Hypot(); err > 1e-9 {
+				t.Errorf("velocity %v, want %v", physics.Velocity(), wantVelocity)
+			}
+		})
+	}
+}
+
+func TestIntegratorLinearDrag(t *testing.T) {
+	const dt, steps = 1.0 / 60, 180
+	gravity, drag, v0 := Vec2{0, 9.8}, Vec2{0.8, 1.5}, Vec2{10, -4}
+	duration := dt * steps
+	// dv/dt = g - k v, per axis
+	exact := func(g, k, v float64) (float64, float64) {
+		terminal := g / k
+		decay := math.Exp(-k * duration)
+		return terminal*duration + (v-terminal)*(1-decay)/k, terminal + (v-terminal)*decay
+	}
+	x, vx := exact(gravity.X, drag.X, v0.X)
+	y, vy := exact(gravity.Y, drag.Y, v0.Y)
+	wantPosition, wantVelocity := Vec2{x, y}, Vec2{vx, vy}
+
+	// each is first, second and fourth order, with errors of about 0.2, 1e-4 and 2e-9 here
+	tolerances := map[Integrator]float64{
+		IntegratorSemiImplicitEuler: 5e-1,
+		IntegratorVerlet:            5e-3,
+		IntegratorRK4:               1e-7,
+	}
+	for _, c := range integrators {
+		t.Run(c.name, func(t *testing.T) {
+			scene, transform, physics := newTestBody(t, c.integrator, Vec2{}, v0)
+			physics.SetGravity(gravity)
+			physics.SetFriction(drag)
+			step(t, scene, dt, steps)
+
+			tolerance := tolerances[c.integrator]
+			if err := transform.Position().Sub(wantPosition).Hypot(); err > tolerance {
+				t.Errorf("position %v, want %v (off by %g, tolerance %g)", transform.Position(), wantPosition, err, tolerance)
+			}
+			if err := physics.Velocity().Sub(wantVelocity).Hypot(); err > tolerance {
+				t.Errorf("velocity %v, want %v (off by %g, tolerance %g)", physics.Velocity(), wantVelocity, err, tolerance)
+			}
+		})
+	}
+}
+
+// forces from components are applied once a step, so a spring's pull doesn't change during the step.
+// semi-implicit euler is symplectic and keeps a spring's energy within about ωh of where it started.
+// verlet and rk4 can only improve on velocity dependent terms (drag), so with the spring they gain energy by a factor
+// of 1+(ωh)²/2 each step, same as plain explicit integration
+func TestIntegratorSpringEnergy(t *testing.T) {
+	const dt, steps = 1.0 / 120, 240 // two periods
+	omega := 2 * math.Pi
+	stiffness := omega * omega
+	energy := func(position, velocity Vec2) float64 {
+		return velocity.Dot(velocity)/2 + stiffness*position.Dot(position)/2
+	}
+
+	for _, c := range integrators {
+		t.Run(c.name, func(t *testing.T) {
+			scene, transform, physics := newTestBody(t, c.integrator, Vec2{1, 0}, Vec2{})
+			start := energy(transform.Position(), physics.Velocity())
+			low, high := 1.0, 1.0
+			for i := 0; i < steps; i++ {
+				physics.ApplyForce(transform.Position().Scaled(-stiffness * physics.Mass()))
+				step(t, scene, dt, 1)
+				ratio := energy(transform.Position(), physics.Velocity()) / start
+				low, high = math.Min(low, ratio), math.Max(high, ratio)
+			}
+
+			if c.integrator == IntegratorSemiImplicitEuler {
+				if bound := omega * dt; low < 1-bound || high > 1+bound {
+					t.Errorf("energy ranged over [%g, %g] of the start, want within %g", low, high, bound)
+				}
+				return
+			}
+			growth := math.Pow(1+omega*omega*dt*dt/2, steps)
+			if low < 1-1e-9 || high > growth*1.01 {
+				t.Errorf("energy ranged over [%g, %g] of the start, want within [1, %g]", low, high, growth*1.01)
+			}
+		})
+	}
+}
@@ -0,0 +1,448 @@
+package nagae
+
+import (
+	"math"
+	"sort"
+)
+
+// JointKind is which constraint a joint enforces
+type JointKind uint8
+
+const (
+	JointDistance  JointKind = iota // keeps the anchors a fixed distance apart
+	JointSpring                     // pulls the anchors towards a rest length, with damping
+	JointRevolute                   // hinges body B around A's anchor, optionally between two angles
+	JointPrismatic                  // slides body B along an axis through A's anchor, optionally between limits
+	JointWeld                       // locks body B to A
+)
+
+// Joint connects two physics bodies, or a body and a fixed world point (when body A is nil).
+// anchors are local to their body (rotating with it), or in world space for a nil body
+type Joint interface {
+	Kind() JointKind
+	BodyA() *Actor
+	BodyB() *Actor
+
+	BreakForce() float64 // zero means unbreakable
+	SetBreakForce(force float64)
+	ReactionForce() float64 // force the joint needed last step
+	Broken() bool
+}
+
+// JointBreakListener is implemented by components that want to know when a joint on their actor breaks
+type JointBreakListener interface {
+	OnJointBreak(joint Joint) error
+}
+
+type jointImpl struct {
+	kind         JointKind
+	bodyA, bodyB *Actor
+	anchorA      Vec2
+	anchorB      Vec2
+
+	// distance and spring
+	length    float64
+	stiffness float64
+	damping   float64
+
+	// revolute and prismatic
+	axis                   Vec2
+	limited                bool
+	lowerLimit, upperLimit float64
+
+	// captured on the first step: the revolute arm, the weld offset, and the weld and prismatic rotation
+	initialized bool
+	restArm     Vec2
+	restAngle   float64
+	restOffset  Vec2
+	restRot     float64
+
+	breakForce    float64
+	reactionForce float64
+	correction    float64 // how far the joint had to move things this step
+	broken        bool
+}
+
+func (j jointImpl) Kind() JointKind              { return j.kind }
+func (j jointImpl) BodyA() *Actor                { return j.bodyA }
+func (j jointImpl) BodyB() *Actor                { return j.bodyB }
+func (j jointImpl) BreakForce() float64          { return j.breakForce }
+func (j *jointImpl) SetBreakForce(force float64) { j.breakForce = force }
+func (j jointImpl) ReactionForce() float64       { return j.reactionForce }
+func (j jointImpl) Broken() bool                 { return j.broken }
+func (j *jointImpl) SetLimits(lower, upper float64) {
+	j.limited, j.lowerLimit, j.upperLimit = true, lower, upper
+}
+func (j *jointImpl) ClearLimits() { j.limited = false }
+
+// LimitedJoint is a revolute (angles, radians) or prismatic (distance along the axis) joint
+type LimitedJoint interface {
+	Joint
+	SetLimits(lower, upper float64)
+	ClearLimits()
+}
+
+func NewDistanceJoint(a, b *Actor, anchorA, anchorB Vec2, length float64) Joint {
+	return &jointImpl{kind: JointDistance, bodyA: a, bodyB: b, anchorA: anchorA, anchorB: anchorB, length: length}
+}
+
+// NewSpringJoint pulls with stiffness * stretch, minus damping * the stretching speed
+func NewSpringJoint(a, b *Actor, anchorA, anchorB Vec2, restLength, stiffness, damping float64) Joint {
+	return &jointImpl{
+		kind: JointSpring, bodyA: a, bodyB: b, anchorA: anchorA, anchorB: anchorB,
+		length: restLength, stiffness: stiffness, damping: damping,
+	}
+}
+
+// NewRevoluteJoint hinges B around A's anchor. B keeps its distance from the hinge and turns to face along its arm.
+// limits are the arm's angle relative to where it started, measured in A's frame
+func NewRevoluteJoint(a, b *Actor, anchorA Vec2) LimitedJoint {
+	return &jointImpl{kind: JointRevolute, bodyA: a, bodyB: b, anchorA: anchorA}
+}
+
+// NewPrismaticJoint keeps B's centre on the line through A's anchor along axis (in A's frame), and B's rotation
+// fixed relative to A's. limits are the distance along the axis from the anchor
+func NewPrismaticJoint(a, b *Actor, anchorA, axis Vec2) LimitedJoint {
+	return &jointImpl{kind: JointPrismatic, bodyA: a, bodyB: b, anchorA: anchorA, axis: axis.Normalized()}
+}
+
+// NewWeldJoint locks B to wherever it is relative to A when the joint first runs
+func NewWeldJoint(a, b *Actor) Joint {
+	return &jointImpl{kind: JointWeld, bodyA: a, bodyB: b}
+}
+
+// jointBody is one end of a joint. a nil actor (or one without a dynamic body) doesn't move
+type jointBody struct {
+	actor      *Actor
+	physics    *componentPhysicsImpl
+	transform  ComponentTransform
+	invMass    float64
+	invInertia float64
+}
+
+func newJointBody(actor *Actor) (jointBody, bool) {
+	if actor == nil {
+		return jointBody{}, true
+	}
+	transformComp, present := actor.GetComponentBySystemType(ComponentSystemTransform)
+	if !present {
+		return jointBody{}, false
+	}
+	body := jointBody{actor: actor, transform: transformComp.(ComponentTransform)}
+	if physicsComp, present := actor.GetComponentBySystemType(ComponentSystemPhysics); present {
+		body.physics = physicsComp.(*componentPhysicsImpl)
+		body.invMass = body.physics.InverseMass()
+		body.invInertia = body.physics.InverseInertia()
+	}
+	return body, true
+}
+
+func (b jointBody) position() Vec2 {
+	if b.transform == nil {
+		return Vec2{}
+	}
+	return b.transform.Position()
+}
+
+func (b jointBody) rotation() float64 {
+	if b.transform == nil {
+		return 0
+	}
+	return b.transform.Rotation()
+}
+
+// worldAnchor turns a local anchor into world space (for a world body it already is)
+func (b jointBody) worldAnchor(local Vec2) Vec2 {
+	if b.transform == nil {
+		return local
+	}
+	local.Rotate(b.rotation())
+	return b.position().Add(local)
+}
+
+func (b jointBody) velocity() Vec2 {
+	if b.physics == nil {
+		return Vec2{}
+	}
+	return b.physics.velocity
+}
+
+func (b jointBody) move(delta Vec2) {
+	if b.invMass > 0 {
+		b.transform.Translate(delta)
+	}
+}
+
+func (b jointBody) turn(delta float64) {
+	if b.invMass > 0 {
+		b.transform.SetRotation(b.rotation() + delta)
+	}
+}
+
+// inverseMassAt is how easily pushing along dir at a world point moves that point, spin included
+func (b jointBody) inverseMassAt(point, dir Vec2) float64 {
+	turn := point.Sub(b.position()).Cross(dir)
+	return b.invMass + b.invInertia*turn*turn
+}
+
+// share splits a correction between two bodies by inverse mass. false if neither can move
+func share(a, b jointBody) (float64, float64, bool) {
+	sum := a.invMass + b.invMass
+	if sum == 0 {
+		return 0, 0, false
+	}
+	return a.invMass / sum, b.invMass / sum, true
+}
+
+// prepare captures rest values and applies spring forces for the step
+func (j *jointImpl) prepare(a, b jointBody) {
+	if !j.initialized {
+		j.initialized = true
+		anchor := a.worldAnchor(j.anchorA)
+		j.restArm = b.position().Sub(anchor)
+		j.restArm.Rotate(-a.rotation())
+		j.restAngle = b.rotation() - j.restArm.Angle() - a.rotation()
+		j.restOffset = j.restArm
+		j.restRot = b.rotation() - a.rotation()
+		if j.kind == JointDistance && j.length <= 0 {
+			j.length = b.worldAnchor(j.anchorB).Sub(anchor).Hypot()
+		}
+	}
+	j.correction = 0
+
+	if j.kind != JointSpring {
+		return
+	}
+	pa, pb := a.worldAnchor(j.anchorA), b.worldAnchor(j.anchorB)
+	d := pb.Sub(pa)
+	length := d.Hypot()
+	if length == 0 {
+		return
+	}
+	n := d.Scaled(1 / length)
+	speed := b.velocity().Sub(a.velocity()).Dot(n)
+	force := j.stiffness*(length-j.length) + j.damping*speed
+	j.reactionForce = math.Abs(force)
+	if a.invMass > 0 {
+		a.physics.ApplyForce(n.Scaled(force))
+	}
+	if b.invMass > 0 {
+		b.physics.ApplyForce(n.Scaled(-force))
+	}
+}
+
+// solve moves the bodies to satisfy the joint, adding up how far they had to go
+func (j *jointImpl) solve(a, b jointBody) {
+	wA, wB, ok := share(a, b)
+	if !ok {
+		return
+	}
+	var drift Vec2
+	switch j.kind {
+	case JointDistance:
+		// anchors off the centre of a body that can spin turn it as well as moving it
+		pa, pb := a.worldAnchor(j.anchorA), b.worldAnchor(j.anchorB)
+		d := pb.Sub(pa)
+		length := d.Hypot()
+		if length == 0 {
+			return
+		}
+		n := d.Scaled(1 / length)
+		lambda := (length - j.length) / (a.inverseMassAt(pa, n) + b.inverseMassAt(pb, n))
+		a.move(n.Scaled(lambda * a.invMass))
+		a.turn(lambda * a.invInertia * pa.Sub(a.position()).Cross(n))
+		b.move(n.Scaled(-lambda * b.invMass))
+		b.turn(-lambda * b.invInertia * pb.Sub(b.position()).Cross(n))
+		j.correction += math.Abs(lambda)
+		return
+	case JointRevolute:
+		anchor := a.worldAnchor(j.anchorA)
+		arm := b.position().Sub(anchor)
+		angle := arm.Angle() - a.rotation()
+		restAngle := j.restArm.Angle()
+		if j.limited {
+			relative := math.Remainder(angle-restAngle, 2*math.Pi)
+			angle = restAngle + math.Max(j.lowerLimit, math.Min(j.upperLimit, relative))
+		}
+		length := j.restArm.Hypot()
+		target := Vec2{math.Cos(angle+a.rotation()) * length, math.Sin(angle+a.rotation()) * length}
+		drift = target.Sub(arm).Scaled(-1)
+	case JointPrismatic:
+		anchor := a.worldAnchor(j.anchorA)
+		axis := j.axis
+		axis.Rotate(a.rotation())
+		offset := b.position().Sub(anchor)
+		along := offset.Dot(axis)
+		if j.limited {
+			along = math.Max(j.lowerLimit, math.Min(j.upperLimit, along))
+		}
+		drift = offset.Sub(axis.Scaled(along))
+	case JointWeld:
+		target := j.restOffset
+		target.Rotate(a.rotation())
+		drift = b.position().Sub(a.worldAnchor(Vec2{}).Add(target))
+	default:
+		return
+	}
+	a.move(drift.Scaled(wA))
+	b.move(drift.Scaled(-wB))
+	j.correction += drift.Hypot() / (a.invMass + b.invMass)
+
+	// revolute joints turn B to face along its arm, welds and prismatic joints share the twist out by inertia
+	switch j.kind {
+	case JointRevolute:
+		arm := b.position().Sub(a.worldAnchor(j.anchorA))
+		b.turn(arm.Angle() + j.restAngle - b.rotation())
+	case JointWeld, JointPrismatic:
+		twist := math.Remainder(b.rotation()-a.rotation()-j.restRot, 2*math.Pi)
+		if sum := a.invInertia + b.invInertia; sum > 0 {
+			wA, wB = a.invInertia/sum, b.invInertia/sum
+		}
+		a.turn(twist * wA)
+		b.turn(-twist * wB)
+	}
+}
+
+func (p physicsSystemImpl) Joints() []Joint {
+	joints := make([]Joint, len(p.joints))
+	for i, joint := range p.joints {
+		joints[i] = joint
+	}
+	return joints
+}
+
+func (p *physicsSystemImpl) AddJoint(joint Joint) error {
+	impl, ok := joint.(*jointImpl)
+	if !ok {
+		return ErrJointUnsupported
+	}
+	for _, existing := range p.joints {
+		if existing == impl {
+			return ErrJointPresent
+		}
+	}
+	p.joints = append(p.joints, impl)
+	return nil
+}
+
+func (p *physicsSystemImpl) RemoveJoint(joint Joint) bool {
+	for i, existing := range p.joints {
+		if Joint(existing) == joint {
+			p.joints = append(p.joints[:i], p.joints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (p physicsSystemImpl) JointIterations() int { return p.jointIterations }
+func (p *physicsSystemImpl) SetJointIterations(iterations int) {
+	if iterations > 0 {
+		p.jointIterations = iterations
+	}
+}
+
+// activeJoints is every joint whose bodies are both usable this step.
+// joints on disabled actors, actors that left the scene or sleeping islands sit idle
+func (p *physicsSystemImpl) activeJoints() ([]*jointImpl, [][2]jointBody) {
+	joints := make([]*jointImpl, 0, len(p.joints))
+	bodies := make([][2]jointBody, 0, len(p.joints))
+	for _, joint := range p.joints {
+		if !p.simulates(joint.bodyA) || !p.simulates(joint.bodyB) {
+			continue
+		}
+		if joint.bodyA != nil && joint.bodyB != nil && idlePair(joint.bodyA, joint.bodyB) {
+			continue
+		}
+		if joint.bodyA == nil && joint.bodyB != nil {
+			if asleep, _ := sleepState(joint.bodyB); asleep {
+				continue
+			}
+		}
+		a, okA := newJointBody(joint.bodyA)
+		b, okB := newJointBody(joint.bodyB)
+		if !okA || !okB || joint.bodyB == nil {
+			continue
+		}
+		joints = append(joints, joint)
+		bodies = append(bodies, [2]jointBody{a, b})
+	}
+	return joints, bodies
+}
+
+func (p *physicsSystemImpl) simulates(actor *Actor) bool {
+	return actor == nil || (actor.enabled && p.attachedScene.actors[actor.actorId] == actor)
+}
+
+// prepareJoints runs before integration so springs can add their forces
+func (p *physicsSystemImpl) prepareJoints() {
+	joints, bodies := p.activeJoints()
+	for i, joint := range joints {
+		joint.prepare(bodies[i][0], bodies[i][1])
+	}
+}
+
+// solveJoints runs after integration. positions are projected iteratively, then velocities
+// pick up however far the projection moved each body
+func (p *physicsSystemImpl) solveJoints(dt float64) error {
+	joints, bodies := p.activeJoints()
+	if len(joints) == 0 {
+		return nil
+	}
+	before := make(map[*Actor]Vec2)
+	beforeRotation := make(map[*Actor]float64)
+	for _, pair := range bodies {
+		for _, body := range pair {
+			if body.invMass > 0 {
+				before[body.actor] = body.position()
+				beforeRotation[body.actor] = body.rotation()
+			}
+		}
+	}
+	for iteration := 0; iteration < p.jointIterations; iteration++ {
+		for i, joint := range joints {
+			if joint.kind != JointSpring {
+				joint.solve(bodies[i][0], bodies[i][1])
+			}
+		}
+	}
+	actors := make([]*Actor, 0, len(before))
+	for actor := range before {
+		actors = append(actors, actor)
+	}
+	sort.Slice(actors, func(i, j int) bool { return actors[i].actorId < actors[j].actorId })
+	for _, actor := range actors {
+		body, _ := newJointBody(actor)
+		body.physics.velocity.Translate(body.position().Sub(before[actor]).Scaled(1 / dt))
+		body.physics.angularVelocity += (body.rotation() - beforeRotation[actor]) / dt
+	}
+
+	broken := make([]*jointImpl, 0)
+	for _, joint := range joints {
+		if joint.kind != JointSpring {
+			// moving an effective mass m by x in one step takes a force of m x / dt^2
+			joint.reactionForce = joint.correction / (dt * dt)
+		}
+		if joint.breakForce > 0 && joint.reactionForce > joint.breakForce {
+			broken = append(broken, joint)
+		}
+	}
+	for _, joint := range broken {
+		joint.broken = true
+		p.RemoveJoint(joint)
+		for _, actor := range []*Actor{joint.bodyA, joint.bodyB} {
+			if actor == nil {
+				continue
+			}
+			if err := actor.eachComponent(func(component Component) error {
+				if listener, ok := component.(JointBreakListener); ok {
+					return listener.OnJointBreak(joint)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package nagae
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPrismaticJointLocksRotation(t *testing.T) {
+	const dt = 1.0 / 60
+	scene, transform, physics := newTestBody(t, IntegratorSemiImplicitEuler, Vec2{}, Vec2{})
+	physics.SetGravity(Vec2{})
+	physics.SetInertia(0.5)
+	joint := NewPrismaticJoint(nil, scene.actors["body"], Vec2{}, Vec2{1, 0})
+	if err := scene.Physics().AddJoint(joint); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 120; i++ {
+		// pushing along the axis but below the centre, which would spin a free body
+		position := transform.Position()
+		physics.ApplyForceAtPoint(Vec2{2, 1}, position.Add(Vec2{0, 0.5}))
+		step(t, scene, dt, 1)
+
+		if rotation := transform.Rotation(); math.Abs(rotation) > 1e-9 {
+			t.Fatalf("step %v: body turned to %v", i, rotation)
+		}
+		if y := transform.Position().Y; math.Abs(y) > 1e-9 {
+			t.Fatalf("step %v: body left the axis, y = %v", i, y)
+		}
+	}
+	if x := transform.Position().X; x <= 1 {
+		t.Errorf("body only slid to x = %v", x)
+	}
+	if spin := physics.AngularVelocity(); math.Abs(spin) > 1e-6 {
+		t.Errorf("body left spinning at %v", spin)
+	}
+}
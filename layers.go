@@ -0,0 +1,137 @@
+package nagae
+
+import (
+	"encoding/json"
+	"io"
+	"math/bits"
+)
+
+const maxCollisionLayers = 32
+
+// CollisionLayers names a scene's collision layers and which of them interact with each other
+type CollisionLayers struct {
+	names  []string
+	matrix [maxCollisionLayers]CollisionLayerMask // matrix[i] is every layer that layer i interacts with
+}
+
+// NewCollisionLayers starts with the default layer (named "default") and every layer interacting
+func NewCollisionLayers() *CollisionLayers {
+	layers := &CollisionLayers{names: []string{"default"}}
+	for i := range layers.matrix {
+		layers.matrix[i] = AllCollisionLayers
+	}
+	return layers
+}
+
+// DefineLayer gets the next free layer for a name. defining a name twice gives the same layer back
+func (c *CollisionLayers) DefineLayer(name string) (CollisionLayerMask, error) {
+	if layer, present := c.Layer(name); present {
+		return layer, nil
+	}
+	if len(c.names) >= maxCollisionLayers {
+		return 0, ErrTooManyLayers
+	}
+	c.names = append(c.names, name)
+	return CollisionLayerMask(1) << uint(len(c.names)-1), nil
+}
+
+func (c CollisionLayers) Layer(name string) (CollisionLayerMask, bool) {
+	for i, layerName := range c.names {
+		if layerName == name {
+			return CollisionLayerMask(1) << uint(i), true
+		}
+	}
+	return 0, false
+}
+
+// Mask combines named layers. unknown names are an error
+func (c CollisionLayers) Mask(names ...string) (CollisionLayerMask, error) {
+	var mask CollisionLayerMask
+	for _, name := range names {
+		layer, present := c.Layer(name)
+		if !present {
+			return 0, ErrLayerNotPresent
+		}
+		mask |= layer
+	}
+	return mask, nil
+}
+
+func (c CollisionLayers) Names() []string { return append([]string(nil), c.names...) }
+
+// SetInteraction sets whether every layer in a interacts with every layer in b (both ways)
+func (c *CollisionLayers) SetInteraction(a, b CollisionLayerMask, interact bool) {
+	for i := 0; i < maxCollisionLayers; i++ {
+		for j := 0; j < maxCollisionLayers; j++ {
+			if a&(1<<uint(i)) == 0 || b&(1<<uint(j)) == 0 {
+				continue
+			}
+			if interact {
+				c.matrix[i] |= 1 << uint(j)
+				c.matrix[j] |= 1 << uint(i)
+			} else {
+				c.matrix[i] &^= 1 << uint(j)
+				c.matrix[j] &^= 1 << uint(i)
+			}
+		}
+	}
+}
+
+func (c *CollisionLayers) SetNamedInteraction(a, b string, interact bool) error {
+	layerA, present := c.Layer(a)
+	if !present {
+		return ErrLayerNotPresent
+	}
+	layerB, present := c.Layer(b)
+	if !present {
+		return ErrLayerNotPresent
+	}
+	c.SetInteraction(layerA, layerB, interact)
+	return nil
+}
+
+// Interacts checks if any layer in a interacts with any layer in b
+func (c CollisionLayers) Interacts(a, b CollisionLayerMask) bool {
+	for a != 0 {
+		i := bits.TrailingZeros32(uint32(a))
+		if c.matrix[i]&b != 0 {
+			return true
+		}
+		a &^= 1 << uint(i)
+	}
+	return false
+}
+
+// shouldCollide checks the layer matrix and both colliders' masks
+func (c CollisionLayers) shouldCollide(a, b ComponentCollider) bool {
+	return a.Layers()&b.Mask() != 0 && b.Layers()&a.Mask() != 0 && c.Interacts(a.Layers(), b.Layers())
+}
+
+type collisionLayersConfig struct {
+	Layers []string    `json:"layers"`
+	Ignore [][2]string `json:"ignore"`
+}
+
+// LoadCollisionLayers reads layer setup from json, ie
+//
+//	{"layers": ["player", "enemy", "bullet"], "ignore": [["bullet", "bullet"], ["player", "bullet"]]}
+//
+// layers are defined in order after "default", and every pair not ignored interacts
+func LoadCollisionLayers(r io.Reader) (*CollisionLayers, error) {
+	config := collisionLayersConfig{}
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
+		return nil, err
+	}
+	layers := NewCollisionLayers()
+	for _, name := range config.Layers {
+		if _, err := layers.DefineLayer(name); err != nil {
+			return nil, err
+		}
+	}
+	for _, pair := range config.Ignore {
+		if err := layers.SetNamedInteraction(pair[0], pair[1], false); err != nil {
+			return nil, err
+		}
+	}
+	return layers, nil
+}
@@ -0,0 +1,168 @@
+package nagae
+
+import (
+	"fmt"
+	"sync"
+)
+
+// LoadProgress is shared between a background scene load and whatever is displaying it
+type LoadProgress struct {
+	mu       sync.Mutex
+	fraction float64
+	status   string
+	done     bool
+	err      error
+}
+
+// Report is called by the loader. fraction is in [0, 1]
+func (p *LoadProgress) Report(fraction float64, status string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fraction = fraction
+	p.status = status
+}
+
+func (p *LoadProgress) Fraction() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.fraction
+}
+
+func (p *LoadProgress) Status() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status
+}
+
+// Done is true once the load has finished, successfully or not
+func (p *LoadProgress) Done() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.done
+}
+
+// Err is why the load failed, if it did
+func (p *LoadProgress) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+func (p *LoadProgress) finish(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done = true
+	p.err = err
+	if err == nil {
+		p.fraction = 1
+	}
+}
+
+// SceneLoader builds a scene on a background goroutine (decoding images, building actors).
+// it must not touch the scene manager or any live scene
+type SceneLoader func(progress *LoadProgress) (*Scene, error)
+
+type loadResult struct {
+	scene *Scene
+	err   error
+}
+
+type pendingLoad struct {
+	progress   *LoadProgress
+	transition SceneTransition
+	result     chan loadResult
+}
+
+func runLoader(loader SceneLoader, progress *LoadProgress) (scene *Scene, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("scene loader panicked: %v", r)
+		}
+	}()
+	return loader(progress)
+}
+
+// LoadSceneAsync switches to the loading scene and runs the loader in the background.
+// once it's done the new scene is added and transitioned to on the main goroutine during Update.
+// a loaded scene replaces one already added with the same id (reloading a level), unless that one is current or an overlay.
+// if it fails the loading scene stays up and the error is on the returned progress
+func (s *SceneManager) LoadSceneAsync(loader SceneLoader, loadingScene SceneId, transition SceneTransition) (*LoadProgress, error) {
+	if s.loading != nil {
+		return nil, ErrLoadActive
+	}
+	if _, present := s.scenes[loadingScene]; !present {
+		return nil, ErrSceneNotPresent
+	}
+	if s.transition != nil {
+		return nil, ErrTransitionActive
+	}
+	load := &pendingLoad{
+		progress:   &LoadProgress{},
+		transition: transition,
+		result:     make(chan loadResult, 1),
+	}
+	if loadingScene != s.currentScene {
+		s.sceneStack = append([]SceneId{loadingScene}, s.sceneStack...)
+		queued := len(s.sceneStack)
+		if err := s.Transition(); err != nil {
+			if len(s.sceneStack) == queued {
+				// the old scene refused to leave, so don't leave the loading scene queued
+				s.sceneStack = s.sceneStack[1:]
+			}
+			return nil, err
+		}
+	}
+	s.loading = load
+	go func() {
+		scene, err := runLoader(loader, load.progress)
+		load.result <- loadResult{scene: scene, err: err}
+	}()
+	return load.progress, nil
+}
+
+// Loading is the progress of the current background load, or nil
+func (s SceneManager) Loading() *LoadProgress {
+	if s.loading == nil {
+		return nil
+	}
+	return s.loading.progress
+}
+
+// pollLoad picks up a finished background load, once any transition into the loading scene is over
+func (s *SceneManager) pollLoad() error {
+	if s.loading == nil || s.transition != nil {
+		return nil
+	}
+	var result loadResult
+	select {
+	case result = <-s.loading.result:
+	default:
+		return nil
+	}
+	load := s.loading
+	s.loading = nil
+
+	if result.err == nil && result.scene == nil {
+		result.err = fmt.Errorf("scene loader returned no scene")
+	}
+	if result.err == nil {
+		result.err = s.replaceScene(result.scene)
+	}
+	load.progress.finish(result.err)
+	if result.err != nil {
+		return nil
+	}
+	s.sceneStack = append([]SceneId{result.scene.Id()}, s.sceneStack...)
+	return s.TransitionWith(load.transition)
+}
+
+// replaceScene adds a scene, swapping out one with the same id as long as it isn't showing
+func (s *SceneManager) replaceScene(scene *Scene) error {
+	for _, layer := range s.layers() {
+		if layer.id == scene.Id() {
+			return ErrScenePresent
+		}
+	}
+	delete(s.scenes, scene.Id())
+	return s.AddScene(scene)
+}
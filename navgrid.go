@@ -0,0 +1,502 @@
+package nagae
+
+import (
+	"container/heap"
+	"math"
+)
+
+// Pathfinder plans a walkable route between two world points. the path skips from and ends at to
+// (or as close to it as can be reached), and is false if there's no way there at all
+type Pathfinder interface {
+	FindPath(from, to Vec2) ([]Vec2, bool)
+}
+
+// avoidingPathfinder can plan around temporary obstacles, like something blocking a path follower
+type avoidingPathfinder interface {
+	FindPathAvoiding(from, to Vec2, avoid []Rect) ([]Vec2, bool)
+}
+
+// GridSearch is how a nav grid searches
+type GridSearch uint8
+
+const (
+	GridSearchAStar     GridSearch = iota
+	GridSearchJumpPoint            // jump point search. the same paths as A*, usually much faster on big open grids
+)
+
+// NavGrid is a uniform grid of walkable and blocked cells. moves go to any of the 8 neighbours,
+// but never cut the corner of a blocked cell
+type NavGrid interface {
+	Pathfinder
+
+	Size() (int, int)
+	CellSize() float64
+	Origin() Vec2 // top left corner of cell 0, 0
+
+	Walkable(x, y int) bool // outside the grid isn't
+	SetWalkable(x, y int, walkable bool)
+	Cell(p Vec2) (int, int, bool) // false outside the grid
+	CellCenter(x, y int) Vec2
+
+	Search() GridSearch
+	SetSearch(search GridSearch)
+	Smooth() bool // pull paths tight with line of sight checks, so they don't follow the grid's zigzags
+	SetSmooth(smooth bool)
+
+	FindPathAvoiding(from, to Vec2, avoid []Rect) ([]Vec2, bool) // cells touching the rects count as blocked
+}
+
+type navGridImpl struct {
+	origin        Vec2
+	cellSize      float64
+	width, height int
+	walkable      []bool
+	search        GridSearch
+	smooth        bool
+}
+
+// NewNavGrid is an all walkable grid
+func NewNavGrid(origin Vec2, cellSize float64, width, height int) NavGrid {
+	if cellSize <= 0 {
+		cellSize = 1
+	}
+	g := &navGridImpl{
+		origin:   origin,
+		cellSize: cellSize,
+		width:    maxInt(width, 0),
+		height:   maxInt(height, 0),
+		smooth:   true,
+	}
+	g.walkable = make([]bool, g.width*g.height)
+	for i := range g.walkable {
+		g.walkable[i] = true
+	}
+	return g
+}
+
+// BuildNavGrid covers an area with a grid, blocking cells that a circle of agentRadius around their centre
+// would touch a collider in. moving things (dynamic bodies) and triggers don't block anything.
+// it sees colliders where they are right now
+func (s *Scene) BuildNavGrid(area Rect, cellSize, agentRadius float64, filter QueryFilter) NavGrid {
+	if cellSize <= 0 {
+		cellSize = 1
+	}
+	size := area.Size()
+	g := NewNavGrid(area.Min, cellSize, int(math.Ceil(size.X/cellSize)), int(math.Ceil(size.Y/cellSize))).(*navGridImpl)
+	if c, ok := s.collisionSystem.(*collisionSystemImpl); ok {
+		c.gather()
+	}
+	filter.IncludeTriggers = false
+	half := cellSize / 2
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			centre := g.CellCenter(x, y)
+			box := Rect{Min: centre, Max: centre}.Expanded(half)
+			for _, hit := range s.OverlapBox(box.Expanded(agentRadius), filter) {
+				if !isDynamic(hit.Actor) {
+					g.walkable[y*g.width+x] = false
+					break
+				}
+			}
+		}
+	}
+	return g
+}
+
+func isDynamic(actor *Actor) bool {
+	physicsComp, present := actor.GetComponentBySystemType(ComponentSystemPhysics)
+	return present && physicsComp.(*componentPhysicsImpl).bodyType == BodyDynamic
+}
+
+func (g navGridImpl) Size() (int, int)             { return g.width, g.height }
+func (g navGridImpl) CellSize() float64            { return g.cellSize }
+func (g navGridImpl) Origin() Vec2                 { return g.origin }
+func (g navGridImpl) Search() GridSearch           { return g.search }
+func (g *navGridImpl) SetSearch(search GridSearch) { g.search = search }
+func (g navGridImpl) Smooth() bool                 { return g.smooth }
+func (g *navGridImpl) SetSmooth(smooth bool)       { g.smooth = smooth }
+func (g navGridImpl) inside(x, y int) bool         { return x >= 0 && y >= 0 && x < g.width && y < g.height }
+func (g navGridImpl) Walkable(x, y int) bool       { return g.inside(x, y) && g.walkable[y*g.width+x] }
+func (g navGridImpl) CellCenter(x, y int) Vec2 {
+	return g.origin.Add(Vec2{(float64(x) + 0.5) * g.cellSize, (float64(y) + 0.5) * g.cellSize})
+}
+
+func (g *navGridImpl) SetWalkable(x, y int, walkable bool) {
+	if g.inside(x, y) {
+		g.walkable[y*g.width+x] = walkable
+	}
+}
+
+func (g navGridImpl) Cell(p Vec2) (int, int, bool) {
+	local := p.Sub(g.origin)
+	x, y := int(math.Floor(local.X/g.cellSize)), int(math.Floor(local.Y/g.cellSize))
+	return x, y, g.inside(x, y)
+}
+
+// clampedCell is the cell under a point, pulled onto the grid
+func (g navGridImpl) clampedCell(p Vec2) (int, int) {
+	x, y, _ := g.Cell(p)
+	return maxInt(0, minInt(g.width-1, x)), maxInt(0, minInt(g.height-1, y))
+}
+
+func (g *navGridImpl) FindPath(from, to Vec2) ([]Vec2, bool) {
+	return g.FindPathAvoiding(from, to, nil)
+}
+
+func (g *navGridImpl) FindPathAvoiding(from, to Vec2, avoid []Rect) ([]Vec2, bool) {
+	if g.width == 0 || g.height == 0 {
+		return nil, false
+	}
+	blocked := make(map[int]bool)
+	world := Rect{Min: g.origin, Max: g.origin.Add(Vec2{float64(g.width) * g.cellSize, float64(g.height) * g.cellSize})}
+	for _, r := range avoid {
+		if !r.Overlaps(world) {
+			// clamping it would block border cells it never touches
+			continue
+		}
+		minX, minY := g.clampedCell(r.Min)
+		maxX, maxY := g.clampedCell(r.Max)
+		for y := minY; y <= maxY; y++ {
+			for x := minX; x <= maxX; x++ {
+				blocked[y*g.width+x] = true
+			}
+		}
+	}
+	open := func(x, y int) bool { return g.Walkable(x, y) && !blocked[y*g.width+x] }
+
+	// agents often stand right up against walls, so start and end from the nearest open cells
+	startX, startY := g.clampedCell(from)
+	goalX, goalY := g.clampedCell(to)
+	var ok bool
+	if startX, startY, ok = g.nearestOpen(startX, startY, open); !ok {
+		return nil, false
+	}
+	if goalX, goalY, ok = g.nearestOpen(goalX, goalY, open); !ok {
+		return nil, false
+	}
+
+	var cells []int
+	if g.search == GridSearchJumpPoint {
+		cells, ok = g.jumpPointSearch(startX, startY, goalX, goalY, open)
+	} else {
+		cells, ok = g.aStar(startX, startY, goalX, goalY, open)
+	}
+	if !ok {
+		return nil, false
+	}
+
+	path := make([]Vec2, 0, len(cells)+1)
+	for _, cell := range cells[1:] {
+		path = append(path, g.CellCenter(cell%g.width, cell/g.width))
+	}
+	if x, y, inside := g.Cell(to); inside && x == goalX && y == goalY {
+		// the goal's own cell is open, so go right to it
+		if len(path) > 0 {
+			path[len(path)-1] = to
+		} else {
+			path = append(path, to)
+		}
+	}
+	path = removeColinear(from, path)
+	if g.smooth {
+		path = g.pullTight(from, path, open)
+	}
+	return path, true
+}
+
+// nearestOpen searches outwards in rings for the closest open cell
+func (g navGridImpl) nearestOpen(x, y int, open func(x, y int) bool) (int, int, bool) {
+	if open(x, y) {
+		return x, y, true
+	}
+	reach := maxInt(g.width, g.height)
+	for ring := 1; ring <= reach; ring++ {
+		bestX, bestY, best := 0, 0, math.Inf(1)
+		for d := -ring; d < ring; d++ {
+			for _, c := range [][2]int{{x + d, y - ring}, {x + ring, y + d}, {x - d, y + ring}, {x - ring, y - d}} {
+				if !open(c[0], c[1]) {
+					continue
+				}
+				if dist := math.Hypot(float64(c[0]-x), float64(c[1]-y)); dist < best {
+					bestX, bestY, best = c[0], c[1], dist
+				}
+			}
+		}
+		if !math.IsInf(best, 1) {
+			return bestX, bestY, true
+		}
+	}
+	return 0, 0, false
+}
+
+// octile is the grid distance between two cells, in cells, moving diagonally where it can
+func octile(dx, dy int) float64 {
+	ax, ay := math.Abs(float64(dx)), math.Abs(float64(dy))
+	return math.Max(ax, ay) + (math.Sqrt2-1)*math.Min(ax, ay)
+}
+
+// neighbours are the cells a move can go to from x, y. diagonals need both sides open
+func (g navGridImpl) neighbours(x, y int, open func(x, y int) bool) [][2]int {
+	found := make([][2]int, 0, 8)
+	for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+		if open(x+d[0], y+d[1]) {
+			found = append(found, [2]int{x + d[0], y + d[1]})
+		}
+	}
+	for _, d := range [][2]int{{1, 1}, {-1, 1}, {1, -1}, {-1, -1}} {
+		if open(x+d[0], y) && open(x, y+d[1]) && open(x+d[0], y+d[1]) {
+			found = append(found, [2]int{x + d[0], y + d[1]})
+		}
+	}
+	return found
+}
+
+func (g navGridImpl) aStar(startX, startY, goalX, goalY int, open func(x, y int) bool) ([]int, bool) {
+	start, goal := startY*g.width+startX, goalY*g.width+goalX
+	return searchGraph(start, goal, func(node int) float64 {
+		return octile(goalX-node%g.width, goalY-node/g.width)
+	}, func(node, parent int, visit func(next int, cost float64)) {
+		x, y := node%g.width, node/g.width
+		for _, n := range g.neighbours(x, y, open) {
+			visit(n[1]*g.width+n[0], octile(n[0]-x, n[1]-y))
+		}
+	})
+}
+
+// jumpPointSearch is A* that only stops at cells where the way forward could change, skipping the straight runs between them.
+// this is the variant that doesn't cut corners
+func (g navGridImpl) jumpPointSearch(startX, startY, goalX, goalY int, open func(x, y int) bool) ([]int, bool) {
+	start, goal := startY*g.width+startX, goalY*g.width+goalX
+	jumps, ok := searchGraph(start, goal, func(node int) float64 {
+		return octile(goalX-node%g.width, goalY-node/g.width)
+	}, func(node, parent int, visit func(next int, cost float64)) {
+		x, y := node%g.width, node/g.width
+		for _, n := range g.prunedNeighbours(x, y, parent, open) {
+			if jx, jy, found := g.jump(n[0], n[1], x, y, goalX, goalY, open); found {
+				visit(jy*g.width+jx, octile(jx-x, jy-y))
+			}
+		}
+	})
+	if !ok {
+		return nil, false
+	}
+	// fill in the cells between jump points so callers see every step
+	cells := []int{jumps[0]}
+	for _, next := range jumps[1:] {
+		x, y := cells[len(cells)-1]%g.width, cells[len(cells)-1]/g.width
+		nx, ny := next%g.width, next/g.width
+		for x != nx || y != ny {
+			x, y = x+sign(nx-x), y+sign(ny-y)
+			cells = append(cells, y*g.width+x)
+		}
+	}
+	return cells, true
+}
+
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
+
+// prunedNeighbours are the directions worth searching from a jump point, given the direction it was reached from
+func (g navGridImpl) prunedNeighbours(x, y, parent int, open func(x, y int) bool) [][2]int {
+	if parent < 0 {
+		return g.neighbours(x, y, open)
+	}
+	dx, dy := sign(x-parent%g.width), sign(y-parent/g.width)
+	found := make([][2]int, 0, 5)
+	add := func(nx, ny int) { found = append(found, [2]int{nx, ny}) }
+	switch {
+	case dx != 0 && dy != 0:
+		nextY, nextX := open(x, y+dy), open(x+dx, y)
+		if nextY {
+			add(x, y+dy)
+		}
+		if nextX {
+			add(x+dx, y)
+		}
+		if nextX && nextY && open(x+dx, y+dy) {
+			add(x+dx, y+dy)
+		}
+	case dx != 0:
+		next, below, above := open(x+dx, y), open(x, y+1), open(x, y-1)
+		if next {
+			add(x+dx, y)
+			if below && open(x+dx, y+1) {
+				add(x+dx, y+1)
+			}
+			if above && open(x+dx, y-1) {
+				add(x+dx, y-1)
+			}
+		}
+		if below {
+			add(x, y+1)
+		}
+		if above {
+			add(x, y-1)
+		}
+	default:
+		next, right, left := open(x, y+dy), open(x+1, y), open(x-1, y)
+		if next {
+			add(x, y+dy)
+			if right && open(x+1, y+dy) {
+				add(x+1, y+dy)
+			}
+			if left && open(x-1, y+dy) {
+				add(x-1, y+dy)
+			}
+		}
+		if right {
+			add(x+1, y)
+		}
+		if left {
+			add(x-1, y)
+		}
+	}
+	return found
+}
+
+// jump runs from px, py through x, y until it finds the goal, a cell with a forced neighbour, or a wall
+func (g navGridImpl) jump(x, y, px, py, goalX, goalY int, open func(x, y int) bool) (int, int, bool) {
+	dx, dy := x-px, y-py
+	for {
+		if !open(x, y) {
+			return 0, 0, false
+		}
+		if x == goalX && y == goalY {
+			return x, y, true
+		}
+		if dx != 0 && dy != 0 {
+			if _, _, found := g.jump(x+dx, y, x, y, goalX, goalY, open); found {
+				return x, y, true
+			}
+			if _, _, found := g.jump(x, y+dy, x, y, goalX, goalY, open); found {
+				return x, y, true
+			}
+		} else if dx != 0 {
+			if (open(x, y-1) && !open(x-dx, y-1)) || (open(x, y+1) && !open(x-dx, y+1)) {
+				return x, y, true
+			}
+		} else if (open(x-1, y) && !open(x-1, y-dy)) || (open(x+1, y) && !open(x+1, y-dy)) {
+			return x, y, true
+		}
+		if !open(x+dx, y) || !open(x, y+dy) {
+			return 0, 0, false
+		}
+		x, y = x+dx, y+dy
+	}
+}
+
+// removeColinear drops waypoints in the middle of straight runs
+func removeColinear(from Vec2, path []Vec2) []Vec2 {
+	if len(path) < 2 {
+		return path
+	}
+	kept := make([]Vec2, 0, len(path))
+	previous := from
+	for i := 0; i < len(path)-1; i++ {
+		if math.Abs(path[i].Sub(previous).Cross(path[i+1].Sub(path[i]))) > 1e-9 {
+			kept = append(kept, path[i])
+			previous = path[i]
+		}
+	}
+	return append(kept, path[len(path)-1])
+}
+
+// pullTight skips every waypoint it can see past
+func (g navGridImpl) pullTight(from Vec2, path []Vec2, open func(x, y int) bool) []Vec2 {
+	tight := make([]Vec2, 0, len(path))
+	current := from
+	for i := 0; i < len(path); {
+		furthest := i
+		for j := len(path) - 1; j > i; j-- {
+			if g.clear(current, path[j], open) {
+				furthest = j
+				break
+			}
+		}
+		tight = append(tight, path[furthest])
+		current = path[furthest]
+		i = furthest + 1
+	}
+	return tight
+}
+
+// clear checks a straight line only crosses open cells, a quarter cell at a time
+func (g navGridImpl) clear(a, b Vec2, open func(x, y int) bool) bool {
+	steps := int(math.Ceil(b.Sub(a).Hypot()/(g.cellSize/4))) + 1
+	for i := 0; i <= steps; i++ {
+		x, y, _ := g.Cell(a.Add(b.Sub(a).Scaled(float64(i) / float64(steps))))
+		if !open(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// searchGraph is A* over integer nodes. expand gets the node's parent (or -1) and calls visit for each neighbour.
+// gives back the nodes from start to goal
+func searchGraph(start, goal int, estimate func(node int) float64, expand func(node, parent int, visit func(next int, cost float64))) ([]int, bool) {
+	cost := map[int]float64{start: 0}
+	parents := map[int]int{start: -1}
+	closed := make(map[int]bool)
+	queue := &pathQueue{{node: start, priority: estimate(start)}}
+	for queue.Len() > 0 {
+		node := heap.Pop(queue).(pathQueueItem).node
+		if closed[node] {
+			continue
+		}
+		if node == goal {
+			nodes := make([]int, 0)
+			for n := goal; n != -1; n = parents[n] {
+				nodes = append(nodes, n)
+			}
+			for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+				nodes[i], nodes[j] = nodes[j], nodes[i]
+			}
+			return nodes, true
+		}
+		closed[node] = true
+		expand(node, parents[node], func(next int, step float64) {
+			if closed[next] {
+				return
+			}
+			total := cost[node] + step
+			if known, present := cost[next]; present && known <= total {
+				return
+			}
+			cost[next], parents[next] = total, node
+			heap.Push(queue, pathQueueItem{node: next, priority: total + estimate(next)})
+		})
+	}
+	return nil, false
+}
+
+type pathQueueItem struct {
+	node     int
+	priority float64
+}
+
+// pathQueue is a min heap on priority, ties broken by node so searches are deterministic
+type pathQueue []pathQueueItem
+
+func (q pathQueue) Len() int { return len(q) }
+func (q pathQueue) Less(i, j int) bool {
+	if q[i].priority == q[j].priority {
+		return q[i].node < q[j].node
+	}
+	return q[i].priority < q[j].priority
+}
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(pathQueueItem)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,205 @@
+package nagae
+
+import (
+	"fmt"
+	"math"
+)
+
+// NavMesh is walkable space made of convex polygons, joined wherever two of them share an edge.
+// paths go polygon to polygon with A*, then get pulled tight through the shared edges (the funnel algorithm)
+type NavMesh interface {
+	Pathfinder
+
+	Polygons() [][]Vec2
+	Locate(p Vec2) (int, bool) // which polygon a point is in
+}
+
+type navPolygon struct {
+	points    []Vec2
+	centroid  Vec2
+	neighbour []int // across each edge (points[i] to points[i+1]), -1 for none
+}
+
+type navMeshImpl struct {
+	polygons []navPolygon
+}
+
+// how close two corners have to be to count as the same one when joining polygons up
+const navMeshWeld = 1e-6
+
+// NewNavMesh joins convex polygons up by their shared edges. points can go round either way
+func NewNavMesh(polygons [][]Vec2) (NavMesh, error) {
+	m := &navMeshImpl{polygons: make([]navPolygon, len(polygons))}
+	for i, points := range polygons {
+		if len(points) < 3 {
+			return nil, fmt.Errorf("nav mesh polygon %d needs at least 3 points", i)
+		}
+		area := 0.0
+		for j, p := range points {
+			area += p.Cross(points[(j+1)%len(points)])
+		}
+		if area == 0 {
+			return nil, fmt.Errorf("nav mesh polygon %d has no area", i)
+		}
+		// every polygon goes round the same way, so shared edges run opposite ways
+		ordered := append([]Vec2(nil), points...)
+		if area < 0 {
+			for a, b := 0, len(ordered)-1; a < b; a, b = a+1, b-1 {
+				ordered[a], ordered[b] = ordered[b], ordered[a]
+			}
+		}
+		for j, p := range ordered {
+			q, r := ordered[(j+1)%len(ordered)], ordered[(j+2)%len(ordered)]
+			if q.Sub(p).Cross(r.Sub(q)) < 0 {
+				return nil, fmt.Errorf("nav mesh polygon %d isn't convex", i)
+			}
+		}
+		_, centroid := polygonArea(ordered)
+		neighbours := make([]int, len(ordered))
+		for j := range neighbours {
+			neighbours[j] = -1
+		}
+		m.polygons[i] = navPolygon{points: ordered, centroid: centroid, neighbour: neighbours}
+	}
+
+	type edgeKey [4]int64
+	weld := func(v Vec2) (int64, int64) {
+		return int64(math.Round(v.X / navMeshWeld)), int64(math.Round(v.Y / navMeshWeld))
+	}
+	edges := make(map[edgeKey][2]int)
+	for i, polygon := range m.polygons {
+		for j, p := range polygon.points {
+			q := polygon.points[(j+1)%len(polygon.points)]
+			px, py := weld(p)
+			qx, qy := weld(q)
+			if other, present := edges[edgeKey{qx, qy, px, py}]; present {
+				m.polygons[i].neighbour[j] = other[0]
+				m.polygons[other[0]].neighbour[other[1]] = i
+				continue
+			}
+			edges[edgeKey{px, py, qx, qy}] = [2]int{i, j}
+		}
+	}
+	return m, nil
+}
+
+func (m navMeshImpl) Polygons() [][]Vec2 {
+	polygons := make([][]Vec2, len(m.polygons))
+	for i, polygon := range m.polygons {
+		polygons[i] = append([]Vec2(nil), polygon.points...)
+	}
+	return polygons
+}
+
+func (m navMeshImpl) Locate(p Vec2) (int, bool) {
+	for i, polygon := range m.polygons {
+		if (convexShape{points: polygon.points}).containsCore(p) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// closest is the polygon a point is in, or the nearest point on the mesh if it's off it
+func (m navMeshImpl) closest(p Vec2) (int, Vec2) {
+	if i, ok := m.Locate(p); ok {
+		return i, p
+	}
+	best, bestPoint, bestDist := -1, p, math.Inf(1)
+	for i, polygon := range m.polygons {
+		for j, a := range polygon.points {
+			point := closestOnSegment(p, a, polygon.points[(j+1)%len(polygon.points)])
+			if dist := point.Sub(p).Hypot(); dist < bestDist {
+				best, bestPoint, bestDist = i, point, dist
+			}
+		}
+	}
+	return best, bestPoint
+}
+
+func (m *navMeshImpl) FindPath(from, to Vec2) ([]Vec2, bool) {
+	if len(m.polygons) == 0 {
+		return nil, false
+	}
+	start, from := m.closest(from)
+	goal, to := m.closest(to)
+	position := func(node int) Vec2 {
+		switch node {
+		case start:
+			return from
+		case goal:
+			return to
+		}
+		return m.polygons[node].centroid
+	}
+	corridor, ok := searchGraph(start, goal, func(node int) float64 {
+		return position(node).Sub(to).Hypot()
+	}, func(node, parent int, visit func(next int, cost float64)) {
+		for _, next := range m.polygons[node].neighbour {
+			if next >= 0 {
+				visit(next, position(node).Sub(position(next)).Hypot())
+			}
+		}
+	})
+	if !ok {
+		return nil, false
+	}
+
+	// the edges crossed along the way. with every polygon wound the same way, the end of an edge is on the left going out
+	portals := make([][2]Vec2, 0, len(corridor)+1)
+	portals = append(portals, [2]Vec2{from, from})
+	for i := 0; i < len(corridor)-1; i++ {
+		polygon := m.polygons[corridor[i]]
+		for j, next := range polygon.neighbour {
+			if next == corridor[i+1] {
+				portals = append(portals, [2]Vec2{polygon.points[(j+1)%len(polygon.points)], polygon.points[j]})
+				break
+			}
+		}
+	}
+	portals = append(portals, [2]Vec2{to, to})
+	return funnel(portals), true
+}
+
+// funnel pulls a path tight through a run of portals (left, right), the first and last being the start and end.
+// it narrows a funnel from the current corner through each portal, and turns a corner whenever one side crosses the other
+func funnel(portals [][2]Vec2) []Vec2 {
+	side := func(a, b, c Vec2) float64 { return b.Sub(a).Cross(c.Sub(a)) }
+	path := make([]Vec2, 0)
+	// portals either side of a corner share it, so the apex can restart on the corner it's already at
+	corner := func(p Vec2) {
+		if len(path) == 0 || path[len(path)-1] != p {
+			path = append(path, p)
+		}
+	}
+	apex, left, right := portals[0][0], portals[0][0], portals[0][1]
+	apexIndex, leftIndex, rightIndex := 0, 0, 0
+	for i := 1; i < len(portals); i++ {
+		l, r := portals[i][0], portals[i][1]
+		if side(apex, right, r) >= 0 {
+			if apex == right || side(apex, left, r) < 0 {
+				right, rightIndex = r, i
+			} else {
+				// the right side crossed over the left, so the path turns round the left corner
+				corner(left)
+				apex, apexIndex = left, leftIndex
+				left, right, leftIndex, rightIndex = apex, apex, apexIndex, apexIndex
+				i = apexIndex
+				continue
+			}
+		}
+		if side(apex, left, l) <= 0 {
+			if apex == left || side(apex, right, l) > 0 {
+				left, leftIndex = l, i
+			} else {
+				corner(right)
+				apex, apexIndex = right, rightIndex
+				left, right, leftIndex, rightIndex = apex, apex, apexIndex, apexIndex
+				i = apexIndex
+				continue
+			}
+		}
+	}
+	corner(portals[len(portals)-1][0])
+	return path
+}
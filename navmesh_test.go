@@ -0,0 +1,44 @@
+package nagae
+
+import (
+	"reflect"
+	"testing"
+)
+
+func navRect(x0, y0, x1, y1 float64) []Vec2 {
+	return []Vec2{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}}
+}
+
+func TestNavMeshCorridorCorners(t *testing.T) {
+	// an L going down then right, turning round (1, 2)
+	l := [][]Vec2{navRect(0, 0, 1, 2), navRect(0, 2, 1, 3), navRect(1, 2, 2, 3), navRect(2, 2, 3, 3)}
+	// a U down the left, across the bottom and up the right, turning round (1, 3) and (2, 3)
+	u := [][]Vec2{navRect(0, 0, 1, 3), navRect(0, 3, 1, 4), navRect(1, 3, 2, 4), navRect(2, 3, 3, 4), navRect(2, 0, 3, 3)}
+
+	cases := []struct {
+		name     string
+		polygons [][]Vec2
+		from, to Vec2
+		want     []Vec2
+	}{
+		{"L forwards", l, Vec2{0.5, 0.5}, Vec2{2.5, 2.5}, []Vec2{{1, 2}, {2.5, 2.5}}},
+		{"L backwards", l, Vec2{2.5, 2.5}, Vec2{0.5, 0.5}, []Vec2{{1, 2}, {0.5, 0.5}}},
+		{"U left to right", u, Vec2{0.5, 0.5}, Vec2{2.5, 0.5}, []Vec2{{1, 3}, {2, 3}, {2.5, 0.5}}},
+		{"U right to left", u, Vec2{2.5, 0.5}, Vec2{0.5, 0.5}, []Vec2{{2, 3}, {1, 3}, {0.5, 0.5}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mesh, err := NewNavMesh(c.polygons)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path, ok := mesh.FindPath(c.from, c.to)
+			if !ok {
+				t.Fatal("no path")
+			}
+			if !reflect.DeepEqual(path, c.want) {
+				t.Errorf("path %v, want %v", path, c.want)
+			}
+		})
+	}
+}
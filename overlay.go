@@ -0,0 +1,84 @@
+package nagae
+
+// LayerFlags control what an overlay scene lets through to the scenes below it
+type LayerFlags uint8
+
+const (
+	LayerUpdateBelow LayerFlags = 1 << iota // scenes below keep updating
+	LayerDrawBelow                          // scenes below keep drawing
+	LayerInputBelow                         // scenes below keep receiving input
+)
+
+func (l LayerFlags) Has(flag LayerFlags) bool { return l&flag != 0 }
+
+type sceneLayer struct {
+	id    SceneId
+	flags LayerFlags
+}
+
+// layers is the current scene followed by the overlays, bottom to top
+func (s SceneManager) layers() []sceneLayer {
+	layers := make([]sceneLayer, 0, len(s.overlays)+1)
+	layers = append(layers, sceneLayer{id: s.currentScene})
+	return append(layers, s.overlays...)
+}
+
+// lowestLayer walks down from the top while layers let the flag through
+func (s SceneManager) lowestLayer(layers []sceneLayer, flag LayerFlags) int {
+	bottom := len(layers) - 1
+	for bottom > 0 && layers[bottom].flags.Has(flag) {
+		bottom--
+	}
+	return bottom
+}
+
+// Overlays are the scenes stacked over the current scene, bottom to top
+func (s SceneManager) Overlays() []SceneId {
+	ids := make([]SceneId, 0, len(s.overlays))
+	for _, layer := range s.overlays {
+		ids = append(ids, layer.id)
+	}
+	return ids
+}
+
+// TopScene is the scene drawn last (the top overlay, or the current scene if there are none)
+func (s SceneManager) TopScene() SceneId {
+	if len(s.overlays) == 0 {
+		return s.currentScene
+	}
+	return s.overlays[len(s.overlays)-1].id
+}
+
+// PushOverlay stacks a scene (pause menu, inventory, dialog) on top of everything else, initializing and entering it
+func (s *SceneManager) PushOverlay(sceneId SceneId, flags LayerFlags) error {
+	scene, present := s.scenes[sceneId]
+	if !present {
+		return ErrSceneNotPresent
+	}
+	for _, layer := range s.layers() {
+		if layer.id == sceneId {
+			return ErrScenePresent
+		}
+	}
+	s.overlays = append(s.overlays, sceneLayer{id: sceneId, flags: flags})
+	err := scene.Init()
+	if err == nil {
+		err = scene.enter(nil)
+	}
+	if err != nil {
+		// don't leave a half set up overlay drawing and updating
+		s.overlays = s.overlays[:len(s.overlays)-1]
+		return err
+	}
+	return nil
+}
+
+// PopOverlay removes the top overlay. the scene below picks up where it was, without being initialized again
+func (s *SceneManager) PopOverlay() error {
+	if len(s.overlays) == 0 {
+		return ErrNoOverlay
+	}
+	top := s.overlays[len(s.overlays)-1]
+	s.overlays = s.overlays[:len(s.overlays)-1]
+	return s.scenes[top.id].exit()
+}
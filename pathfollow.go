@@ -0,0 +1,248 @@
+package nagae
+
+import "math"
+
+// PathFollowerConfig tunes how a path follower moves and when it gives up on its path
+type PathFollowerConfig struct {
+	Speed          float64 // top speed, in world units per second
+	MaxForce       float64 // the most steering force it can use. zero means it gets to its desired velocity straight away
+	ArriveDistance float64 // how close counts as reaching a waypoint
+	SlowDistance   float64 // starts slowing down this far from the end. zero means it doesn't
+
+	RepathInterval float64 // the least time between plans, so being blocked doesn't plan every frame
+	StuckTime      float64 // plans again after making no progress for this long. zero means never
+	AvoidTime      float64 // how long something found blocking the way is planned around (by pathfinders that can)
+	Blocking       QueryFilter
+}
+
+func DefaultPathFollowerConfig() PathFollowerConfig {
+	return PathFollowerConfig{
+		Speed:          2,
+		ArriveDistance: 0.1,
+		SlowDistance:   0.5,
+		RepathInterval: 0.5,
+		StuckTime:      1,
+		AvoidTime:      3,
+	}
+}
+
+// ComponentPathFollower walks its actor along paths from a Pathfinder (a NavGrid or a NavMesh).
+// it steers through ComponentPhysics with forces if the body's dynamic or by setting its velocity if it's kinematic,
+// and moves the transform directly without one. it plans again when something blocks the way to the next waypoint
+// or it stops making progress
+type ComponentPathFollower interface {
+	Component
+
+	Config() PathFollowerConfig
+	SetConfig(config PathFollowerConfig)
+	Pathfinder() Pathfinder
+	SetPathfinder(pathfinder Pathfinder)
+
+	SetTarget(target Vec2) // plans on the next update
+	Target() (Vec2, bool)
+	Stop() // forgets the target and brakes to a halt
+	Replan()
+
+	Path() []Vec2 // waypoints still to go
+	Arrived() bool
+	Failed() bool // the pathfinder couldn't find a way to the target. it keeps trying every RepathInterval
+}
+
+type pathAvoid struct {
+	bounds Rect
+	until  float64
+}
+
+type componentPathFollowerImpl struct {
+	ComponentImpl
+
+	config     PathFollowerConfig
+	pathfinder Pathfinder
+
+	target    Vec2
+	hasTarget bool
+	path      []Vec2
+	planned   bool
+	arrived   bool
+	failed    bool
+	stopping  bool
+
+	time         float64
+	lastPlan     float64
+	bestDistance float64 // closest it's been to the next waypoint
+	lastProgress float64
+	avoid        []pathAvoid
+}
+
+func (c componentPathFollowerImpl) Config() PathFollowerConfig           { return c.config }
+func (c *componentPathFollowerImpl) SetConfig(config PathFollowerConfig) { c.config = config }
+func (c componentPathFollowerImpl) Pathfinder() Pathfinder               { return c.pathfinder }
+func (c *componentPathFollowerImpl) SetPathfinder(pathfinder Pathfinder) {
+	c.pathfinder = pathfinder
+	c.Replan()
+}
+func (c componentPathFollowerImpl) Target() (Vec2, bool) { return c.target, c.hasTarget }
+func (c componentPathFollowerImpl) Arrived() bool        { return c.arrived }
+func (c componentPathFollowerImpl) Failed() bool         { return c.failed }
+func (c componentPathFollowerImpl) Path() []Vec2         { return append([]Vec2(nil), c.path...) }
+func (c *componentPathFollowerImpl) Replan()             { c.planned = false }
+
+func (c *componentPathFollowerImpl) SetTarget(target Vec2) {
+	c.target, c.hasTarget = target, true
+	c.arrived, c.failed, c.stopping = false, false, false
+	c.Replan()
+}
+
+func (c *componentPathFollowerImpl) Stop() {
+	c.hasTarget, c.path, c.planned = false, nil, false
+	c.arrived, c.failed, c.stopping = false, false, true
+}
+
+func (c *componentPathFollowerImpl) plan(position Vec2) {
+	c.planned, c.lastPlan = true, c.time
+	c.bestDistance, c.lastProgress = math.Inf(1), c.time
+	if c.pathfinder == nil {
+		c.path, c.failed = nil, true
+		return
+	}
+	kept := c.avoid[:0]
+	avoid := make([]Rect, 0, len(c.avoid))
+	for _, a := range c.avoid {
+		if a.until > c.time {
+			kept = append(kept, a)
+			avoid = append(avoid, a.bounds)
+		}
+	}
+	c.avoid = kept
+	var path []Vec2
+	var ok bool
+	if avoiding, can := c.pathfinder.(avoidingPathfinder); can && len(avoid) > 0 {
+		path, ok = avoiding.FindPathAvoiding(position, c.target, avoid)
+	}
+	if !ok {
+		// what's in the way might be the only way through, and it could move. better to head for it than give up
+		path, ok = c.pathfinder.FindPath(position, c.target)
+	}
+	c.path, c.failed = path, !ok
+}
+
+// blocker is whatever's in the way between the actor and its next waypoint
+func (c *componentPathFollowerImpl) blocker(position Vec2) (*Actor, bool) {
+	if c.boundActor.parentScene == nil || len(c.path) == 0 {
+		return nil, false
+	}
+	filter := c.config.Blocking
+	filter.IncludeTriggers = false
+	filter.Exclude = append(append([]*Actor(nil), filter.Exclude...), c.boundActor)
+	offset := c.path[0].Sub(position)
+	hit, ok := c.boundActor.parentScene.Raycast(position, offset, offset.Hypot(), filter)
+	if !ok {
+		return nil, false
+	}
+	return hit.Actor, true
+}
+
+func (c *componentPathFollowerImpl) Update(dt float64) error {
+	if c.boundActor == nil {
+		return nil
+	}
+	transformComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemTransform)
+	if !present {
+		return nil
+	}
+	transform := transformComp.(ComponentTransform)
+	position := transform.Position()
+	c.time += dt
+
+	if c.hasTarget && !c.arrived && (!c.planned || c.failed && c.time-c.lastPlan >= c.config.RepathInterval) {
+		// failed plans get tried again, in case the way opens up
+		c.plan(position)
+	}
+	for len(c.path) > 0 && c.path[0].Sub(position).Hypot() <= c.config.ArriveDistance {
+		c.path = c.path[1:]
+		c.bestDistance, c.lastProgress = math.Inf(1), c.time
+	}
+	if c.hasTarget && c.planned && !c.failed && len(c.path) == 0 {
+		c.arrived = true
+	}
+
+	if len(c.path) > 0 && c.time-c.lastPlan >= c.config.RepathInterval {
+		if distance := c.path[0].Sub(position).Hypot(); distance < c.bestDistance-c.config.ArriveDistance/2 {
+			c.bestDistance, c.lastProgress = distance, c.time
+		}
+		if blocker, blocked := c.blocker(position); blocked {
+			if bounds, ok := c.boundActor.parentScene.Spatial().Bounds(blocker); ok && isDynamic(blocker) {
+				// it's not part of the level, so the pathfinder doesn't know about it. keep our own size clear of it too
+				if own, ok := c.boundActor.parentScene.Spatial().Bounds(c.boundActor); ok {
+					size := own.Size()
+					bounds = bounds.Expanded(math.Max(size.X, size.Y) / 2)
+				}
+				c.avoid = append(c.avoid, pathAvoid{bounds: bounds, until: c.time + c.config.AvoidTime})
+			}
+			c.plan(position)
+		} else if c.config.StuckTime > 0 && c.time-c.lastProgress >= c.config.StuckTime {
+			c.plan(position)
+		}
+	}
+
+	if !c.hasTarget && !c.stopping {
+		// not going anywhere, so leave the actor alone
+		return nil
+	}
+	desired := Vec2{}
+	if len(c.path) > 0 {
+		offset := c.path[0].Sub(position)
+		speed := c.config.Speed
+		if c.config.SlowDistance > 0 {
+			remaining := offset.Hypot()
+			for i := 1; i < len(c.path) && remaining < c.config.SlowDistance; i++ {
+				remaining += c.path[i].Sub(c.path[i-1]).Hypot()
+			}
+			speed *= math.Min(1, remaining/c.config.SlowDistance)
+		}
+		desired = offset.Normalized().Scaled(speed)
+	}
+	if c.steer(transform, desired, dt) && !c.hasTarget {
+		c.stopping = false
+	}
+	return nil
+}
+
+// steer moves towards the desired velocity however the actor can be moved. true once it's there
+func (c *componentPathFollowerImpl) steer(transform ComponentTransform, desired Vec2, dt float64) bool {
+	physicsComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemPhysics)
+	if !present {
+		transform.Translate(desired.Scaled(dt))
+		return true
+	}
+	physics := physicsComp.(ComponentPhysics)
+	switch physics.BodyType() {
+	case BodyKinematic:
+		physics.SetVelocity(desired)
+	case BodyDynamic:
+		change := desired.Sub(physics.Velocity())
+		if dt <= 0 || change.Hypot() < 1e-6 {
+			return change.Hypot() < 1e-6
+		}
+		force := change.Scaled(physics.Mass() / dt)
+		if c.config.MaxForce > 0 && force.Hypot() > c.config.MaxForce {
+			force = force.Normalized().Scaled(c.config.MaxForce)
+		}
+		physics.ApplyForce(force)
+		return false
+	}
+	return true
+}
+
+func NewComponentPathFollower(pathfinder Pathfinder, config PathFollowerConfig) (ComponentPathFollower, error) {
+	baseComponent, err := NewComponent(ComponentSystemCustom, ComponentTypePathFollower, "path follower")
+	if err != nil {
+		return nil, err
+	}
+	return &componentPathFollowerImpl{
+		ComponentImpl: *baseComponent.(*ComponentImpl),
+		config:        config,
+		pathfinder:    pathfinder,
+		avoid:         make([]pathAvoid, 0),
+	}, nil
+}
@@ -0,0 +1,293 @@
+package nagae
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+const (
+	// how far past the overlap the narrow phase looks (in pixels) to work out which way a masked contact faces
+	maskNormalMargin = 3
+	// most points sampled for one masked contact. big sprites against each other get sampled more coarsely
+	maskMaxSamples = 1 << 14
+)
+
+// pixelMask is which pixels of an image are solid. coordinates are in pixels from the image's centre
+type pixelMask struct {
+	width, height int
+	solid         []bool
+	count         int
+	bounds        Rect // of the solid pixels
+
+	// sums over solid pixel centres, for the moment of inertia
+	sumX, sumY, sumXX, sumYY float64
+}
+
+// newPixelMask marks pixels with alpha above the threshold as solid
+func newPixelMask(img image.Image, threshold uint8) *pixelMask {
+	b := img.Bounds()
+	m := &pixelMask{width: b.Dx(), height: b.Dy(), solid: make([]bool, b.Dx()*b.Dy())}
+	limit := uint32(threshold) * 0x101
+	first := true
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			if _, _, _, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA(); a <= limit {
+				continue
+			}
+			m.solid[y*m.width+x] = true
+			m.count++
+			corner := Vec2{float64(x) - float64(m.width)/2, float64(y) - float64(m.height)/2}
+			pixel := Rect{Min: corner, Max: corner.Add(Vec2{1, 1})}
+			if first {
+				m.bounds, first = pixel, false
+			} else {
+				m.bounds = m.bounds.Union(pixel)
+			}
+			centre := pixel.Center()
+			m.sumX += centre.X
+			m.sumY += centre.Y
+			m.sumXX += centre.X * centre.X
+			m.sumYY += centre.Y * centre.Y
+		}
+	}
+	return m
+}
+
+// at is whether a pixel (from the image's top left) is solid. outside the image isn't
+func (m pixelMask) at(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return false
+	}
+	return m.solid[y*m.width+x]
+}
+
+// ComponentPixelCollider is cut out of a sprite's alpha channel, for when bounding shapes aren't precise enough.
+// it lines up with a sprite component drawn on the same actor: one pixel is one world unit before the transform's scale,
+// and it turns with the transform. the broad phase (and queries, sweeps and character controllers) use the box around
+// the solid pixels, then contacts are worked out pixel by pixel. animated sprites get a mask per frame, built as it's first shown
+type ComponentPixelCollider interface {
+	ComponentCollider
+
+	Sprite() Sprite
+	Threshold() uint8 // pixels with alpha above this are solid
+	SetThreshold(threshold uint8)
+}
+
+// maskedCollider is a collider the narrow phase has to check point by point, past its bounding shape.
+// gives a test for world points and how far apart pixels are in the world
+type maskedCollider interface {
+	masked() (func(p Vec2) bool, float64, bool)
+}
+
+type componentPixelColliderImpl struct {
+	componentColliderImpl
+
+	sprite    Sprite
+	threshold uint8
+	masks     map[*ebiten.Image]*pixelMask
+}
+
+func (c componentPixelColliderImpl) Sprite() Sprite   { return c.sprite }
+func (c componentPixelColliderImpl) Threshold() uint8 { return c.threshold }
+func (c *componentPixelColliderImpl) SetThreshold(threshold uint8) {
+	c.threshold = threshold
+	c.masks = make(map[*ebiten.Image]*pixelMask)
+	c.boundActor.moved()
+}
+
+// frame is the image the sprite is showing. animated sprites are read without ticking them
+func (c componentPixelColliderImpl) frame() *ebiten.Image {
+	if animated, ok := c.sprite.(*animatedSpriteImpl); ok {
+		if len(animated.loadedFrames) == 0 {
+			return nil
+		}
+		return animated.loadedFrames[animated.currentFrame]
+	}
+	return c.sprite.Image()
+}
+
+// mask is the current frame's mask. nil if there's nothing solid
+func (c *componentPixelColliderImpl) mask() *pixelMask {
+	img := c.frame()
+	if img == nil {
+		return nil
+	}
+	mask, present := c.masks[img]
+	if !present {
+		mask = newPixelMask(img, c.threshold)
+		c.masks[img] = mask
+	}
+	if mask.count == 0 {
+		return nil
+	}
+	return mask
+}
+
+// placement is where the mask's centre is and how it's scaled and turned
+func (c componentPixelColliderImpl) placement() (Vec2, Vec2, float64, bool) {
+	if c.boundActor == nil {
+		return Vec2{}, Vec2{}, 0, false
+	}
+	transformComp, present := c.boundActor.GetComponentBySystemType(ComponentSystemTransform)
+	if !present {
+		return Vec2{}, Vec2{}, 0, false
+	}
+	transform := transformComp.(ComponentTransform)
+	scale, rot := transform.Scale(), transform.Rotation()
+	origin := c.offset
+	origin.MultVec(scale)
+	origin.Rotate(rot)
+	origin.Translate(transform.Position())
+	return origin, scale, rot, true
+}
+
+// worldShape is the box around the current frame's solid pixels
+func (c *componentPixelColliderImpl) worldShape() (convexShape, bool) {
+	mask := c.mask()
+	if mask == nil {
+		return convexShape{}, false
+	}
+	min, max := mask.bounds.Min, mask.bounds.Max
+	c.local = convexShape{points: []Vec2{min, {max.X, min.Y}, max, {min.X, max.Y}}}
+	return c.componentColliderImpl.worldShape()
+}
+
+func (c *componentPixelColliderImpl) Bounds() (Rect, bool) {
+	shape, ok := c.worldShape()
+	if !ok {
+		return Rect{}, false
+	}
+	return shape.bounds(), true
+}
+
+// unitInertia treats every solid pixel as a little square of equal mass
+func (c *componentPixelColliderImpl) unitInertia() float64 {
+	mask := c.mask()
+	if mask == nil {
+		return 0
+	}
+	scale := Vec2{1, 1}
+	if _, s, _, ok := c.placement(); ok {
+		scale = s
+	}
+	n := float64(mask.count)
+	o := c.offset
+	x := mask.sumXX/n + 2*o.X*mask.sumX/n + o.X*o.X
+	y := mask.sumYY/n + 2*o.Y*mask.sumY/n + o.Y*o.Y
+	return scale.X*scale.X*(x+1.0/12) + scale.Y*scale.Y*(y+1.0/12)
+}
+
+func (c *componentPixelColliderImpl) masked() (func(p Vec2) bool, float64, bool) {
+	mask := c.mask()
+	origin, scale, rot, ok := c.placement()
+	if mask == nil || !ok || scale.X == 0 || scale.Y == 0 {
+		return nil, 0, false
+	}
+	halfW, halfH := float64(mask.width)/2, float64(mask.height)/2
+	contains := func(p Vec2) bool {
+		local := p.Sub(origin)
+		local.Rotate(-rot)
+		return mask.at(int(math.Floor(local.X/scale.X+halfW)), int(math.Floor(local.Y/scale.Y+halfH)))
+	}
+	return contains, math.Min(math.Abs(scale.X), math.Abs(scale.Y)), true
+}
+
+// maskedContact redoes a contact found between bounding shapes when either side is a pixel mask.
+// the overlap is sampled on a grid a pixel apart, and faces from the solid area of A near it towards B's
+func maskedContact(a, b colliderEntry, manifold ContactManifold) (ContactManifold, bool) {
+	maskA, okA := a.collider.(maskedCollider)
+	maskB, okB := b.collider.(maskedCollider)
+	if !okA && !okB {
+		return manifold, true
+	}
+	pitch := math.Inf(1)
+	insideA, insideB := a.shape.contains, b.shape.contains
+	if okA {
+		contains, pixel, ok := maskA.masked()
+		if !ok {
+			return ContactManifold{}, false
+		}
+		insideA, pitch = contains, pixel
+	}
+	if okB {
+		contains, pixel, ok := maskB.masked()
+		if !ok {
+			return ContactManifold{}, false
+		}
+		insideB, pitch = contains, math.Min(pitch, pixel)
+	}
+
+	window := a.bounds.Intersection(b.bounds).Expanded(pitch * maskNormalMargin)
+	size := window.Size()
+	if samples := size.X * size.Y / (pitch * pitch); samples > maskMaxSamples {
+		pitch *= math.Sqrt(samples / maskMaxSamples)
+	}
+	var sumA, sumB, sumOverlap Vec2
+	var countA, countB int
+	overlap := make([]Vec2, 0)
+	for y := window.Min.Y + pitch/2; y < window.Max.Y; y += pitch {
+		for x := window.Min.X + pitch/2; x < window.Max.X; x += pitch {
+			p := Vec2{x, y}
+			inA, inB := insideA(p), insideB(p)
+			if inA {
+				sumA.Translate(p)
+				countA++
+			}
+			if inB {
+				sumB.Translate(p)
+				countB++
+			}
+			if inA && inB {
+				sumOverlap.Translate(p)
+				overlap = append(overlap, p)
+			}
+		}
+	}
+	if len(overlap) == 0 {
+		return ContactManifold{}, false
+	}
+
+	normal := sumB.Scaled(1 / float64(countB)).Sub(sumA.Scaled(1 / float64(countA))).Normalized()
+	if normal.Hypot() == 0 {
+		normal = manifold.Normal
+	}
+	// depth is the overlap's area spread over how wide it is across the normal
+	tangent := normal.Perp()
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, p := range overlap {
+		d := p.Dot(tangent)
+		min, max = math.Min(min, d), math.Max(max, d)
+	}
+	area := float64(len(overlap)) * pitch * pitch
+	return ContactManifold{
+		Normal: normal,
+		Depth:  area / (max - min + pitch),
+		Points: []Vec2{sumOverlap.Scaled(1 / float64(len(overlap)))},
+	}, true
+}
+
+// NewComponentPixelCollider cuts a collider out of a sprite (static or animated), where alpha is above the threshold
+func NewComponentPixelCollider(sprite Sprite, threshold uint8) (ComponentPixelCollider, error) {
+	if sprite == nil {
+		return nil, fmt.Errorf("pixel collider needs a sprite")
+	}
+	w, h := sprite.GetSize()
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("pixel collider sprite size (%f, %f) must be positive", w, h)
+	}
+	base, err := newComponentCollider(ComponentTypeColliderPixel, "pixel collider", convexShape{
+		points: []Vec2{{-w / 2, -h / 2}, {w / 2, -h / 2}, {w / 2, h / 2}, {-w / 2, h / 2}},
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+	return &componentPixelColliderImpl{
+		componentColliderImpl: *base.(*componentColliderImpl),
+		sprite:                sprite,
+		threshold:             threshold,
+		masks:                 make(map[*ebiten.Image]*pixelMask),
+	}, nil
+}
@@ -0,0 +1,217 @@
+package nagae
+
+import (
+	"math"
+	"sort"
+)
+
+// QueryFilter narrows down what a spatial query can hit
+type QueryFilter struct {
+	Layers          CollisionLayerMask // zero means every layer
+	QueryLayers     CollisionLayerMask // if set, the query acts like a collider on these layers and the layer matrix applies
+	Tags            []string           // if set, actors need at least one of these
+	IncludeTriggers bool
+	Exclude         []*Actor
+}
+
+func (f QueryFilter) accepts(entry colliderEntry, layers *CollisionLayers) bool {
+	if f.Layers != 0 && entry.collider.Layers()&f.Layers == 0 {
+		return false
+	}
+	if f.QueryLayers != 0 && !layers.Interacts(f.QueryLayers, entry.collider.Layers()) {
+		return false
+	}
+	if entry.collider.Trigger() && !f.IncludeTriggers {
+		return false
+	}
+	for _, excluded := range f.Exclude {
+		if excluded == entry.actor {
+			return false
+		}
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, tag := range f.Tags {
+		if entry.actor.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// QueryHit is one actor found by a query. Normal points out of the hit actor's surface
+type QueryHit struct {
+	Actor    *Actor
+	Point    Vec2
+	Normal   Vec2
+	Distance float64 // along the ray, zero for overlap queries
+}
+
+// candidates uses the scene's spatial index to find entries that might touch the rect
+func (c *collisionSystemImpl) candidates(r Rect, filter QueryFilter) []colliderEntry {
+	found := make([]colliderEntry, 0)
+	for _, actor := range c.attachedScene.Spatial().QueryRect(r) {
+		index, present := c.entryIndex[actor]
+		if !present {
+			continue
+		}
+		entry := c.entries[index]
+		if entry.bounds.Overlaps(r) && filter.accepts(entry, c.attachedScene.collisionLayers) {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+// raySegment intersects a ray with a segment, giving the distance along the ray
+func raySegment(origin, dir, a, b Vec2) (float64, bool) {
+	edge := b.Sub(a)
+	denom := dir.Cross(edge)
+	if denom == 0 {
+		return 0, false
+	}
+	diff := a.Sub(origin)
+	t, u := diff.Cross(edge)/denom, diff.Cross(dir)/denom
+	if t < 0 || u < 0 || u > 1 {
+		return 0, false
+	}
+	return t, true
+}
+
+func rayCircle(origin, dir, center Vec2, radius float64) (float64, bool) {
+	diff := origin.Sub(center)
+	b := diff.Dot(dir)
+	c := diff.Dot(diff) - radius*radius
+	disc := b*b - c
+	if disc < 0 {
+		return 0, false
+	}
+	t := -b - math.Sqrt(disc)
+	if t < 0 {
+		return 0, false
+	}
+	return t, true
+}
+
+// rayShape casts a ray (dir normalized) at a shape, giving the distance and surface normal of the first hit
+func rayShape(origin, dir Vec2, shape convexShape) (float64, Vec2, bool) {
+	if shape.contains(origin) {
+		return 0, dir.Scaled(-1), true
+	}
+	best, bestNormal, hit := math.Inf(1), Vec2{}, false
+	try := func(t float64, normal Vec2) {
+		if t < best {
+			best, bestNormal, hit = t, normal, true
+		}
+	}
+	if shape.radius > 0 {
+		for _, p := range shape.points {
+			if t, ok := rayCircle(origin, dir, p, shape.radius); ok {
+				try(t, origin.Add(dir.Scaled(t)).Sub(p).Normalized())
+			}
+		}
+	}
+	center := shape.center()
+	for _, edge := range shape.edges() {
+		if edge[0] == edge[1] {
+			continue
+		}
+		normal := edge[1].Sub(edge[0]).Perp().Normalized()
+		normals := []Vec2{normal, normal.Scaled(-1)}
+		if len(shape.points) >= 3 {
+			if normal.Dot(edge[0].Sub(center)) < 0 {
+				normal = normal.Scaled(-1)
+			}
+			normals = []Vec2{normal}
+		}
+		for _, n := range normals {
+			offset := n.Scaled(shape.radius)
+			if t, ok := raySegment(origin, dir, edge[0].Add(offset), edge[1].Add(offset)); ok && dir.Dot(n) < 0 {
+				try(t, n)
+			}
+		}
+	}
+	return best, bestNormal, hit
+}
+
+// RaycastAll finds every collider along a ray, nearest first. queries see colliders as of the last collision update
+func (s *Scene) RaycastAll(origin, direction Vec2, maxDistance float64, filter QueryFilter) []QueryHit {
+	c := s.collisionSystem.(*collisionSystemImpl)
+	dir := direction.Normalized()
+	hits := make([]QueryHit, 0)
+	if dir == (Vec2{}) {
+		return hits
+	}
+	// an infinite ray along an axis would put NaN (0 * Inf) in its bounds, so axes it doesn't move on stay put
+	end := origin
+	if dir.X != 0 {
+		end.X += dir.X * maxDistance
+	}
+	if dir.Y != 0 {
+		end.Y += dir.Y * maxDistance
+	}
+	bounds := Rect{Min: origin, Max: origin}.Union(Rect{Min: end, Max: end})
+	for _, entry := range c.candidates(bounds, filter) {
+		t, normal, hit := rayShape(origin, dir, entry.shape)
+		if !hit || t > maxDistance {
+			continue
+		}
+		hits = append(hits, QueryHit{
+			Actor:    entry.actor,
+			Point:    origin.Add(dir.Scaled(t)),
+			Normal:   normal,
+			Distance: t,
+		})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Distance < hits[j].Distance })
+	return hits
+}
+
+// Raycast finds the first collider along a ray
+func (s *Scene) Raycast(origin, direction Vec2, maxDistance float64, filter QueryFilter) (QueryHit, bool) {
+	hits := s.RaycastAll(origin, direction, maxDistance, filter)
+	if len(hits) == 0 {
+		return QueryHit{}, false
+	}
+	return hits[0], true
+}
+
+func (s *Scene) overlapShape(shape convexShape, filter QueryFilter) []QueryHit {
+	c := s.collisionSystem.(*collisionSystemImpl)
+	hits := make([]QueryHit, 0)
+	for _, entry := range c.candidates(shape.bounds(), filter) {
+		manifold, hit := collideShapes(shape, entry.shape)
+		if !hit {
+			continue
+		}
+		hits = append(hits, QueryHit{
+			Actor:  entry.actor,
+			Point:  manifold.Points[0],
+			Normal: manifold.Normal.Scaled(-1),
+		})
+	}
+	return hits
+}
+
+// OverlapCircle finds every collider touching a circle
+func (s *Scene) OverlapCircle(center Vec2, radius float64, filter QueryFilter) []QueryHit {
+	return s.overlapShape(convexShape{points: []Vec2{center}, radius: radius}, filter)
+}
+
+// OverlapBox finds every collider touching a rect
+func (s *Scene) OverlapBox(box Rect, filter QueryFilter) []QueryHit {
+	return s.overlapShape(convexShape{points: []Vec2{
+		box.Min, {box.Max.X, box.Min.Y}, box.Max, {box.Min.X, box.Max.Y},
+	}}, filter)
+}
+
+// ActorsInRadius is OverlapCircle when you only care about who's there
+func (s *Scene) ActorsInRadius(center Vec2, radius float64, filter QueryFilter) []*Actor {
+	hits := s.OverlapCircle(center, radius, filter)
+	actors := make([]*Actor, len(hits))
+	for i, hit := range hits {
+		actors[i] = hit.Actor
+	}
+	return actors
+}
@@ -0,0 +1,187 @@
+package nagae
+
+import "math"
+
+const (
+	// how much of the overlap is pushed out each frame, and how much is left alone to stop jitter
+	positionCorrectionPercent = 0.8
+	positionCorrectionSlop    = 0.01
+)
+
+// contactBody is one side of a contact for resolution. actors without a physics component act static
+type contactBody struct {
+	physics    *componentPhysicsImpl
+	transform  ComponentTransform
+	invMass    float64
+	invInertia float64
+}
+
+func newContactBody(actor *Actor) (contactBody, bool) {
+	transformComp, present := actor.GetComponentBySystemType(ComponentSystemTransform)
+	if !present {
+		return contactBody{}, false
+	}
+	body := contactBody{transform: transformComp.(ComponentTransform)}
+	if physicsComp, present := actor.GetComponentBySystemType(ComponentSystemPhysics); present {
+		body.physics = physicsComp.(*componentPhysicsImpl)
+		body.invMass = body.physics.InverseMass()
+		body.invInertia = body.physics.InverseInertia()
+	}
+	return body, true
+}
+
+// arm is from the body's centre (its transform's position) to a world point
+func (b contactBody) arm(point Vec2) Vec2 {
+	return point.Sub(b.transform.Position())
+}
+
+// velocityAt is how fast a point stuck to the body is moving, spin included
+func (b contactBody) velocityAt(point Vec2) Vec2 {
+	if b.physics == nil {
+		return Vec2{}
+	}
+	return b.physics.velocity.Add(b.arm(point).Perp().Scaled(b.physics.angularVelocity))
+}
+
+// inverseMassAlong is how easily an impulse along dir at the point moves the point
+func (b contactBody) inverseMassAlong(point, dir Vec2) float64 {
+	turn := b.arm(point).Cross(dir)
+	return b.invMass + b.invInertia*turn*turn
+}
+
+func (b contactBody) restitution() float64 {
+	if b.physics == nil {
+		return 0
+	}
+	return b.physics.restitution
+}
+
+func (b contactBody) surfaceFriction() float64 {
+	if b.physics == nil {
+		return 0.3
+	}
+	return b.physics.surfaceFriction
+}
+
+func (b contactBody) applyImpulse(impulse, point Vec2) {
+	if b.invMass == 0 {
+		return
+	}
+	b.physics.velocity.Translate(impulse.Scaled(b.invMass))
+	b.physics.angularVelocity += b.arm(point).Cross(impulse) * b.invInertia
+}
+
+// contactConstraint is a contact being solved. impulses go through each contact point and build up over
+// several passes over every contact, so stacks and two point contacts settle
+type contactConstraint struct {
+	a, b   contactBody
+	normal Vec2
+	depth  float64
+	points []Vec2
+	mu     float64
+
+	targets          []float64 // how fast each point should be separating
+	normalImpulses   []float64 // pushed through each point so far
+	frictionImpulses []float64
+}
+
+func newContactConstraint(contact Contact) (*contactConstraint, bool) {
+	a, okA := newContactBody(contact.A)
+	b, okB := newContactBody(contact.B)
+	if !okA || !okB || a.invMass+b.invMass == 0 {
+		return nil, false
+	}
+	c := &contactConstraint{
+		a: a, b: b,
+		normal:           contact.Manifold.Normal,
+		depth:            contact.Manifold.Depth,
+		points:           contact.Manifold.Points,
+		mu:               math.Sqrt(a.surfaceFriction() * b.surfaceFriction()),
+		targets:          make([]float64, len(contact.Manifold.Points)),
+		normalImpulses:   make([]float64, len(contact.Manifold.Points)),
+		frictionImpulses: make([]float64, len(contact.Manifold.Points)),
+	}
+	restitution := math.Max(a.restitution(), b.restitution())
+	for i, point := range c.points {
+		if normalSpeed := b.velocityAt(point).Sub(a.velocityAt(point)).Dot(c.normal); normalSpeed < 0 {
+			c.targets[i] = -restitution * normalSpeed
+		}
+	}
+	return c, true
+}
+
+func (c *contactConstraint) solveVelocity() {
+	a, b, normal := c.a, c.b, c.normal
+	for i, point := range c.points {
+		normalSpeed := b.velocityAt(point).Sub(a.velocityAt(point)).Dot(normal)
+		j := (c.targets[i] - normalSpeed) / (a.inverseMassAlong(point, normal) + b.inverseMassAlong(point, normal))
+		// the total pushed through a point can never pull the bodies together
+		total := math.Max(c.normalImpulses[i]+j, 0)
+		j, c.normalImpulses[i] = total-c.normalImpulses[i], total
+		a.applyImpulse(normal.Scaled(-j), point)
+		b.applyImpulse(normal.Scaled(j), point)
+
+		// friction works against whatever sliding is left, capped by the normal impulse
+		relative := b.velocityAt(point).Sub(a.velocityAt(point))
+		tangent := normal.Perp()
+		jt := -relative.Dot(tangent) / (a.inverseMassAlong(point, tangent) + b.inverseMassAlong(point, tangent))
+		limit := c.normalImpulses[i] * c.mu
+		total = math.Max(-limit, math.Min(limit, c.frictionImpulses[i]+jt))
+		jt, c.frictionImpulses[i] = total-c.frictionImpulses[i], total
+		a.applyImpulse(tangent.Scaled(-jt), point)
+		b.applyImpulse(tangent.Scaled(jt), point)
+	}
+}
+
+// correctPosition pushes out part of the overlap directly, so resting contacts don't slowly sink
+func (c *contactConstraint) correctPosition() {
+	invMassSum := c.a.invMass + c.b.invMass
+	correction := math.Max(c.depth-positionCorrectionSlop, 0) / invMassSum * positionCorrectionPercent
+	c.a.transform.Translate(c.normal.Scaled(-correction * c.a.invMass))
+	c.b.transform.Translate(c.normal.Scaled(correction * c.b.invMass))
+}
+
+// contactImpulses is what a contact pushed through its points last step, used to warm start the next step
+type contactImpulses struct {
+	normal, friction []float64
+}
+
+// warmStart applies last step's impulses up front, so resting stacks don't have to build them up from nothing every step
+func (c *contactConstraint) warmStart(previous contactImpulses) {
+	if len(previous.normal) != len(c.points) {
+		return
+	}
+	tangent := c.normal.Perp()
+	for i, point := range c.points {
+		c.normalImpulses[i], c.frictionImpulses[i] = previous.normal[i], previous.friction[i]
+		impulse := c.normal.Scaled(previous.normal[i]).Add(tangent.Scaled(previous.friction[i]))
+		c.a.applyImpulse(impulse.Scaled(-1), point)
+		c.b.applyImpulse(impulse, point)
+	}
+}
+
+// resolveContacts solves every contact together with sequential impulses, then corrects overlaps.
+// gives back the impulses each contact ended up with, for warm starting
+func resolveContacts(contacts []Contact, iterations int, previous map[[2]ActorId]contactImpulses) map[[2]ActorId]contactImpulses {
+	constraints := make([]*contactConstraint, 0, len(contacts))
+	keys := make([][2]ActorId, 0, len(contacts))
+	for _, contact := range contacts {
+		if constraint, ok := newContactConstraint(contact); ok {
+			key := actorPair{contact.A, contact.B}.key()
+			constraint.warmStart(previous[key])
+			constraints = append(constraints, constraint)
+			keys = append(keys, key)
+		}
+	}
+	for iteration := 0; iteration < iterations; iteration++ {
+		for _, constraint := range constraints {
+			constraint.solveVelocity()
+		}
+	}
+	impulses := make(map[[2]ActorId]contactImpulses, len(constraints))
+	for i, constraint := range constraints {
+		constraint.correctPosition()
+		impulses[keys[i]] = contactImpulses{normal: constraint.normalImpulses, friction: constraint.frictionImpulses}
+	}
+	return impulses
+}
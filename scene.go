@@ -4,14 +4,35 @@ import (
 	"github.com/hajimehoshi/ebiten"
 )
 
+// SceneHook is called when a scene is exited
+type SceneHook func(scene *Scene) error
+
+// SceneEnterHook is called when a scene is entered. payload is whatever the transition into it carried (or nil)
+type SceneEnterHook func(scene *Scene, payload interface{}) error
+
 type Scene struct {
 	sceneId SceneId
 
+	enterHook SceneEnterHook
+	exitHook  SceneHook
+
 	actors  map[ActorId]*Actor
 	manager *SceneManager
 
-	physicsSystem  PhysicsSystem
-	graphicsSystem GraphicsSystem
+	receivesInput bool
+	initialized   bool
+
+	collisionLayers *CollisionLayers
+
+	chunks      map[ChunkId][]ActorId
+	actorChunks map[ActorId]ChunkId
+
+	spatial      *spatialIndexImpl
+	spatialDirty map[*Actor]bool // moved since the index last caught up
+
+	physicsSystem   PhysicsSystem
+	collisionSystem CollisionSystem
+	graphicsSystem  GraphicsSystem
 }
 
 func NewScene(sceneId SceneId) *Scene {
@@ -19,9 +40,21 @@ func NewScene(sceneId SceneId) *Scene {
 		sceneId: sceneId,
 
 		actors: make(map[ActorId]*Actor),
+
+		receivesInput: true,
+
+		collisionLayers: NewCollisionLayers(),
+
+		chunks:      make(map[ChunkId][]ActorId),
+		actorChunks: make(map[ActorId]ChunkId),
+
+		spatial:      newSpatialIndex(0),
+		spatialDirty: make(map[*Actor]bool),
 	}
 	physics := NewPhysicsSystem(scene)
 	scene.physicsSystem = physics
+	collision := NewCollisionSystem(scene)
+	scene.collisionSystem = collision
 	graphics := NewGraphicsSystem(scene)
 	scene.graphicsSystem = graphics
 	return scene
@@ -30,10 +63,45 @@ func NewScene(sceneId SceneId) *Scene {
 func (s Scene) Id() SceneId            { return s.sceneId }
 func (s Scene) Manager() *SceneManager { return s.manager }
 
+func (s Scene) Physics() PhysicsSystem     { return s.physicsSystem }
+func (s Scene) Collision() CollisionSystem { return s.collisionSystem }
+
+func (s Scene) CollisionLayers() *CollisionLayers { return s.collisionLayers }
+func (s *Scene) SetCollisionLayers(layers *CollisionLayers) {
+	if layers == nil {
+		// back to everything colliding with everything
+		layers = NewCollisionLayers()
+	}
+	s.collisionLayers = layers
+}
+
+// ReceivesInput is false while an overlay on top of this scene is swallowing input
+func (s Scene) ReceivesInput() bool { return s.receivesInput }
+
+func (s *Scene) SetEnterHook(hook SceneEnterHook) { s.enterHook = hook }
+func (s *Scene) SetExitHook(hook SceneHook)       { s.exitHook = hook }
+
+func (s *Scene) enter(payload interface{}) error {
+	if s.enterHook == nil {
+		return nil
+	}
+	return s.enterHook(s, payload)
+}
+
+func (s *Scene) exit() error {
+	if s.exitHook == nil {
+		return nil
+	}
+	return s.exitHook(s)
+}
+
 func (s *Scene) Init() error {
 	if err := s.physicsSystem.Init(); err != nil {
 		return err
 	}
+	if err := s.collisionSystem.Init(); err != nil {
+		return err
+	}
 	if err := s.graphicsSystem.Init(); err != nil {
 		return err
 	}
@@ -42,6 +110,7 @@ func (s *Scene) Init() error {
 			return err
 		}
 	}
+	s.initialized = true
 	return nil
 }
 
@@ -49,10 +118,16 @@ func (s *Scene) Update(dt float64) error {
 	if err := s.physicsSystem.Update(dt); err != nil {
 		return err
 	}
+	if err := s.collisionSystem.Update(dt); err != nil {
+		return err
+	}
 	if err := s.graphicsSystem.Update(dt); err != nil {
 		return err
 	}
 	for _, actor := range s.actors {
+		if !actor.enabled {
+			continue
+		}
 		if err := actor.Update(dt); err != nil {
 			return err
 		}
@@ -81,6 +156,7 @@ func (s *Scene) AddActor(actor *Actor) bool {
 	}
 	actor.parentScene = s
 	s.actors[actor.actorId] = actor
+	s.refreshSpatialActor(actor)
 	return true
 }
 
@@ -88,6 +164,9 @@ func (s *Scene) RemoveActor(actorId ActorId) bool {
 	if _, present := s.GetActor(actorId); !present {
 		return false
 	}
+	s.spatial.Remove(s.actors[actorId])
+	delete(s.spatialDirty, s.actors[actorId])
 	delete(s.actors, actorId)
+	s.forgetChunkActor(actorId)
 	return true
 }
@@ -0,0 +1,223 @@
+package nagae
+
+import (
+	"math"
+	"sort"
+)
+
+// PhysicsStats counts bodies for profiling. it's filled in at the start of every physics step
+type PhysicsStats struct {
+	Bodies   int // dynamic bodies
+	Awake    int
+	Sleeping int
+	Islands  int // groups of dynamic bodies touching or jointed together
+}
+
+// unionFind groups indices together, used to build islands
+type unionFind []int
+
+func newUnionFind(n int) unionFind {
+	u := make(unionFind, n)
+	for i := range u {
+		u[i] = i
+	}
+	return u
+}
+
+func (u unionFind) find(i int) int {
+	for u[i] != i {
+		u[i] = u[u[i]]
+		i = u[i]
+	}
+	return i
+}
+
+func (u unionFind) union(a, b int) {
+	if rootA, rootB := u.find(a), u.find(b); rootA != rootB {
+		u[rootB] = rootA
+	}
+}
+
+// sleepState is whether an actor's body is asleep, and whether it's moving under its own power
+// (an awake dynamic body or any kinematic one). things with neither, like static bodies, can't disturb anything
+func sleepState(actor *Actor) (asleep bool, active bool) {
+	physicsComp, present := actor.GetComponentBySystemType(ComponentSystemPhysics)
+	if !present {
+		return false, false
+	}
+	physics := physicsComp.(*componentPhysicsImpl)
+	switch physics.bodyType {
+	case BodyDynamic:
+		return physics.sleeping, !physics.sleeping
+	case BodyKinematic:
+		return false, true
+	}
+	return false, false
+}
+
+// idlePair is true if nothing in a pair can move the other, and at least one is asleep
+func idlePair(a, b *Actor) bool {
+	asleepA, activeA := sleepState(a)
+	asleepB, activeB := sleepState(b)
+	return !activeA && !activeB && (asleepA || asleepB)
+}
+
+// updateSleep runs at the start of a step, once the last step's contacts have been resolved.
+// bodies touching or jointed together form an island, which only sleeps once every body in it has been still long enough.
+// one body being disturbed wakes its whole island
+func (p *physicsSystemImpl) updateSleep(dt float64) {
+	bodies := make([]*componentPhysicsImpl, 0)
+	actors := make([]*Actor, 0)
+	for _, actor := range p.attachedScene.actors {
+		if !actor.enabled {
+			continue
+		}
+		if _, present := actor.GetComponentByType(ComponentTypeCharacterController); present {
+			continue
+		}
+		physicsComp, present := actor.GetComponentBySystemType(ComponentSystemPhysics)
+		if !present || physicsComp.(*componentPhysicsImpl).bodyType != BodyDynamic {
+			continue
+		}
+		actors = append(actors, actor)
+	}
+	sort.Slice(actors, func(i, j int) bool { return actors[i].actorId < actors[j].actorId })
+	index := make(map[*Actor]int, len(actors))
+	for i, actor := range actors {
+		physicsComp, _ := actor.GetComponentBySystemType(ComponentSystemPhysics)
+		bodies = append(bodies, physicsComp.(*componentPhysicsImpl))
+		index[actor] = i
+	}
+
+	linear, angular := p.sleepLinear, p.sleepAngular
+	still := func(body *componentPhysicsImpl) bool {
+		return body.velocity.Hypot() < linear && math.Abs(body.angularVelocity) < angular
+	}
+	for _, body := range bodies {
+		switch {
+		case body.sleeping && !still(body):
+			// something pushed it without going through the component (a contact or a joint)
+			body.WakeUp()
+		case !body.sleeping && body.canSleep && still(body):
+			body.sleepTime += dt
+		case !body.sleeping:
+			body.sleepTime = 0
+		}
+	}
+
+	for _, body := range bodies {
+		if body.sleeping && p.lostSupport(body) {
+			// nothing pushes it when what it's resting on goes away, so it has to be woken
+			body.WakeUp()
+		}
+	}
+
+	islands := newUnionFind(len(bodies))
+	supports := make(map[int][]*Actor)
+	for _, contact := range p.attachedScene.collisionSystem.Contacts() {
+		a, okA := index[contact.A]
+		b, okB := index[contact.B]
+		switch {
+		case okA && okB:
+			islands.union(a, b)
+		case okA:
+			supports[a] = append(supports[a], contact.B)
+		case okB:
+			supports[b] = append(supports[b], contact.A)
+		}
+	}
+	for _, joint := range p.joints {
+		a, okA := index[joint.bodyA]
+		b, okB := index[joint.bodyB]
+		if okA && okB {
+			islands.union(a, b)
+		}
+	}
+	// sleeping bodies don't get checked against each other, so they remember which island they fell asleep in.
+	// a body woken on its own still does until this runs, so the rest of its island wakes with it
+	sleptWith := make(map[uint64]int)
+	for i, body := range bodies {
+		if body.island == 0 {
+			continue
+		}
+		if first, present := sleptWith[body.island]; present {
+			islands.union(first, i)
+		} else {
+			sleptWith[body.island] = i
+		}
+	}
+	members := make(map[int][]int)
+	roots := make([]int, 0)
+	for i := range bodies {
+		root := islands.find(i)
+		if _, present := members[root]; !present {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], i)
+	}
+
+	p.stats = PhysicsStats{Bodies: len(bodies), Islands: len(roots)}
+	for _, root := range roots {
+		ready := p.sleepEnabled
+		if ready {
+			p.lastIsland++
+		}
+		for _, i := range members[root] {
+			if body := bodies[i]; !body.sleeping && (!body.canSleep || body.sleepTime < p.timeToSleep) {
+				ready = false
+				break
+			}
+		}
+		for _, i := range members[root] {
+			body := bodies[i]
+			if ready {
+				if !body.sleeping {
+					body.supports = supports[i]
+				}
+				body.sleeping, body.island = true, p.lastIsland
+				body.velocity, body.angularVelocity = Vec2{}, 0
+			} else {
+				if body.sleeping {
+					body.WakeUp()
+				}
+				body.island, body.supports = 0, nil
+			}
+		}
+	}
+	for _, body := range bodies {
+		if body.sleeping {
+			p.stats.Sleeping++
+		} else {
+			p.stats.Awake++
+		}
+	}
+}
+
+// lostSupport is whether something a sleeping body was resting on has been removed or disabled, or is a kinematic body on the move
+func (p physicsSystemImpl) lostSupport(body *componentPhysicsImpl) bool {
+	for _, support := range body.supports {
+		if actor, present := p.attachedScene.actors[support.actorId]; !present || actor != support || !support.enabled {
+			return true
+		}
+		physicsComp, present := support.GetComponentBySystemType(ComponentSystemPhysics)
+		if !present {
+			continue
+		}
+		if physics := physicsComp.(*componentPhysicsImpl); physics.bodyType == BodyKinematic &&
+			(physics.velocity != (Vec2{}) || physics.angularVelocity != 0) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p physicsSystemImpl) Stats() PhysicsStats { return p.stats }
+
+func (p physicsSystemImpl) SleepEnabled() bool                  { return p.sleepEnabled }
+func (p *physicsSystemImpl) SetSleepEnabled(enabled bool)       { p.sleepEnabled = enabled }
+func (p physicsSystemImpl) TimeToSleep() float64                { return p.timeToSleep }
+func (p *physicsSystemImpl) SetTimeToSleep(seconds float64)     { p.timeToSleep = seconds }
+func (p physicsSystemImpl) SleepThresholds() (float64, float64) { return p.sleepLinear, p.sleepAngular }
+func (p *physicsSystemImpl) SetSleepThresholds(linear, angular float64) {
+	p.sleepLinear, p.sleepAngular = linear, angular
+}
@@ -0,0 +1,519 @@
+package nagae
+
+import (
+	"math"
+	"sort"
+)
+
+// SpatialIndex buckets actor bounds into a uniform grid, so finding what's near something doesn't mean walking every actor.
+// every scene has one (Scene.Spatial) that it keeps up to date by itself, and NewSpatialIndex makes standalone ones for custom systems.
+// results are always in actor id order, except Nearest which is nearest first.
+// the scene's index fits its cell size to what it's tracking until it's given one
+type SpatialIndex interface {
+	CellSize() float64
+	SetCellSize(size float64) // re-buckets everything, and stops it fitting itself
+
+	Insert(actor *Actor, bounds Rect) // moves the actor if it's already in
+	Update(actor *Actor, bounds Rect) // same as Insert
+	Remove(actor *Actor)
+	Bounds(actor *Actor) (Rect, bool)
+	Len() int
+
+	QueryRect(r Rect) []*Actor                        // bounds overlapping the rect
+	QueryRadius(center Vec2, radius float64) []*Actor // bounds within radius of the centre
+	Nearest(point Vec2, k int) []*Actor               // the k with bounds closest to the point (zero inside them)
+}
+
+type spatialEntry struct {
+	bounds    Rect
+	min, max  cellKey
+	oversized bool // covers too many cells to bucket, kept in the oversized list instead
+}
+
+type cellKey struct {
+	x, y int
+}
+
+const (
+	spatialCellLimit     = 1 << 20 // cell coordinates are clamped to this either way, so huge or infinite bounds still index
+	spatialMaxEntryCells = 256     // entries covering more cells than this skip the grid
+)
+
+type spatialIndexImpl struct {
+	cellSize  float64
+	cells     map[cellKey][]*Actor
+	entries   map[*Actor]spatialEntry
+	oversized map[*Actor]bool
+
+	fitting   bool    // cell size follows the size of what's in it
+	extentSum float64 // of every entry with a finite, non zero size
+	sized     int
+}
+
+// NewSpatialIndex makes an index with the given cell size. 0 or less fits the cell size to whatever's put in it,
+// so it works the same whether things are sized in units or pixels
+func NewSpatialIndex(cellSize float64) SpatialIndex {
+	return newSpatialIndex(cellSize)
+}
+
+func newSpatialIndex(cellSize float64) *spatialIndexImpl {
+	fitting := cellSize <= 0
+	if fitting {
+		cellSize = 1
+	}
+	return &spatialIndexImpl{
+		cellSize:  cellSize,
+		fitting:   fitting,
+		cells:     make(map[cellKey][]*Actor),
+		entries:   make(map[*Actor]spatialEntry),
+		oversized: make(map[*Actor]bool),
+	}
+}
+
+func (s spatialIndexImpl) CellSize() float64 { return s.cellSize }
+func (s *spatialIndexImpl) SetCellSize(size float64) {
+	if size <= 0 {
+		return
+	}
+	s.fitting = false
+	s.resize(size)
+}
+
+func (s *spatialIndexImpl) resize(size float64) {
+	if size == s.cellSize {
+		return
+	}
+	entries := s.entries
+	s.cellSize = size
+	s.cells = make(map[cellKey][]*Actor)
+	s.entries = make(map[*Actor]spatialEntry, len(entries))
+	s.oversized = make(map[*Actor]bool)
+	s.extentSum, s.sized = 0, 0
+	for actor, entry := range entries {
+		s.Insert(actor, entry.bounds)
+	}
+}
+
+// fitCellSize moves a fitting index to cells about twice the average entry's size, rounded to a power of two.
+// re-bucketing is a pass over everything, so it only happens once the cells are well off
+func (s *spatialIndexImpl) fitCellSize() {
+	if !s.fitting || s.sized == 0 {
+		return
+	}
+	target := 2 * s.extentSum / float64(s.sized)
+	if target > s.cellSize/4 && target < s.cellSize*4 {
+		return
+	}
+	s.resize(math.Pow(2, math.Round(math.Log2(target))))
+}
+
+// entryExtent is what an entry counts for when fitting, its larger side. false if it shouldn't count
+func entryExtent(bounds Rect) (float64, bool) {
+	size := bounds.Size()
+	extent := math.Max(size.X, size.Y)
+	return extent, extent > 0 && !math.IsInf(extent, 0) && !math.IsNaN(extent)
+}
+
+func (s *spatialIndexImpl) track(bounds Rect, sign int) {
+	if extent, ok := entryExtent(bounds); ok {
+		s.extentSum += float64(sign) * extent
+		s.sized += sign
+	}
+}
+
+func (s spatialIndexImpl) Len() int { return len(s.entries) }
+
+func (s spatialIndexImpl) Bounds(actor *Actor) (Rect, bool) {
+	entry, present := s.entries[actor]
+	return entry.bounds, present
+}
+
+// cellRange is the cells a rect covers, clamped to the cell limit. NaN counts as unbounded
+func (s spatialIndexImpl) cellRange(r Rect) (cellKey, cellKey) {
+	return cellKey{s.cellCoord(r.Min.X, -spatialCellLimit), s.cellCoord(r.Min.Y, -spatialCellLimit)},
+		cellKey{s.cellCoord(r.Max.X, spatialCellLimit), s.cellCoord(r.Max.Y, spatialCellLimit)}
+}
+
+func (s spatialIndexImpl) cellCoord(v, ifNaN float64) int {
+	cell := math.Floor(v / s.cellSize)
+	if math.IsNaN(cell) {
+		cell = ifNaN
+	}
+	return int(math.Max(-spatialCellLimit, math.Min(spatialCellLimit, cell)))
+}
+
+// cellCount is how many cells are between min and max, in floats so it can't overflow
+func cellCount(min, max cellKey) float64 {
+	return float64(max.x-min.x+1) * float64(max.y-min.y+1)
+}
+
+func (s *spatialIndexImpl) Insert(actor *Actor, bounds Rect) {
+	min, max := s.cellRange(bounds)
+	if entry, present := s.entries[actor]; present {
+		if entry.min == min && entry.max == max {
+			// still in the same cells, nothing to move
+			s.track(entry.bounds, -1)
+			s.track(bounds, 1)
+			entry.bounds = bounds
+			s.entries[actor] = entry
+			return
+		}
+		s.Remove(actor)
+	}
+	s.track(bounds, 1)
+	if cellCount(min, max) > spatialMaxEntryCells {
+		s.entries[actor] = spatialEntry{bounds: bounds, min: min, max: max, oversized: true}
+		s.oversized[actor] = true
+		return
+	}
+	s.entries[actor] = spatialEntry{bounds: bounds, min: min, max: max}
+	for x := min.x; x <= max.x; x++ {
+		for y := min.y; y <= max.y; y++ {
+			key := cellKey{x, y}
+			s.cells[key] = append(s.cells[key], actor)
+		}
+	}
+}
+
+func (s *spatialIndexImpl) Update(actor *Actor, bounds Rect) { s.Insert(actor, bounds) }
+
+func (s *spatialIndexImpl) Remove(actor *Actor) {
+	entry, present := s.entries[actor]
+	if !present {
+		return
+	}
+	delete(s.entries, actor)
+	s.track(entry.bounds, -1)
+	if entry.oversized {
+		delete(s.oversized, actor)
+		return
+	}
+	for x := entry.min.x; x <= entry.max.x; x++ {
+		for y := entry.min.y; y <= entry.max.y; y++ {
+			key := cellKey{x, y}
+			cell := s.cells[key]
+			for i, other := range cell {
+				if other == actor {
+					cell = append(cell[:i], cell[i+1:]...)
+					break
+				}
+			}
+			if len(cell) == 0 {
+				delete(s.cells, key)
+			} else {
+				s.cells[key] = cell
+			}
+		}
+	}
+}
+
+// gather collects every actor in the cells between min and max (and every oversized one) that passes keep, each once,
+// in actor id order
+func (s spatialIndexImpl) gather(min, max cellKey, keep func(entry spatialEntry) bool) []*Actor {
+	seen := make(map[*Actor]bool)
+	found := make([]*Actor, 0)
+	for actor := range s.oversized {
+		if keep(s.entries[actor]) {
+			found = append(found, actor)
+		}
+	}
+	// walk whichever is smaller, the cells in range or the cells that exist
+	if cellCount(min, max) > float64(len(s.cells)) {
+		for key, cell := range s.cells {
+			if key.x < min.x || key.x > max.x || key.y < min.y || key.y > max.y {
+				continue
+			}
+			found = s.collect(cell, seen, found, keep)
+		}
+	} else {
+		for x := min.x; x <= max.x; x++ {
+			for y := min.y; y <= max.y; y++ {
+				found = s.collect(s.cells[cellKey{x, y}], seen, found, keep)
+			}
+		}
+	}
+	sortActors(found)
+	return found
+}
+
+func (s spatialIndexImpl) collect(cell []*Actor, seen map[*Actor]bool, found []*Actor, keep func(entry spatialEntry) bool) []*Actor {
+	for _, actor := range cell {
+		if seen[actor] {
+			continue
+		}
+		seen[actor] = true
+		if keep(s.entries[actor]) {
+			found = append(found, actor)
+		}
+	}
+	return found
+}
+
+func sortActors(actors []*Actor) {
+	sort.Slice(actors, func(i, j int) bool { return actors[i].actorId < actors[j].actorId })
+}
+
+func (s *spatialIndexImpl) QueryRect(r Rect) []*Actor {
+	s.fitCellSize()
+	min, max := s.cellRange(r)
+	return s.gather(min, max, func(entry spatialEntry) bool { return entry.bounds.Overlaps(r) })
+}
+
+func (s *spatialIndexImpl) QueryRadius(center Vec2, radius float64) []*Actor {
+	s.fitCellSize()
+	min, max := s.cellRange(Rect{Min: center, Max: center}.Expanded(radius))
+	return s.gather(min, max, func(entry spatialEntry) bool { return rectDistance(entry.bounds, center) <= radius })
+}
+
+// Nearest searches rings of cells outwards from the point until nothing further out could be closer.
+// if the point is far from everything it just checks every actor instead
+func (s *spatialIndexImpl) Nearest(point Vec2, k int) []*Actor {
+	s.fitCellSize()
+	if k <= 0 || len(s.entries) == 0 {
+		return []*Actor{}
+	}
+	var lowest, highest cellKey
+	first := true
+	for key := range s.cells {
+		if first {
+			lowest, highest, first = key, key, false
+			continue
+		}
+		lowest = cellKey{minInt(lowest.x, key.x), minInt(lowest.y, key.y)}
+		highest = cellKey{maxInt(highest.x, key.x), maxInt(highest.y, key.y)}
+	}
+	centre, _ := s.cellRange(Rect{Min: point, Max: point})
+	reach := maxInt(maxInt(centre.x-lowest.x, highest.x-centre.x), maxInt(centre.y-lowest.y, highest.y-centre.y))
+
+	found := make([]spatialCandidate, 0, len(s.oversized))
+	seen := make(map[*Actor]bool)
+	for actor := range s.oversized {
+		found = append(found, spatialCandidate{actor, rectDistance(s.entries[actor].bounds, point)})
+	}
+	visit := func(key cellKey) {
+		for _, actor := range s.cells[key] {
+			if !seen[actor] {
+				seen[actor] = true
+				found = append(found, spatialCandidate{actor, rectDistance(s.entries[actor].bounds, point)})
+			}
+		}
+	}
+	if side := float64(2*reach + 1); len(s.cells) == 0 || side*side > 4*float64(len(s.cells)) {
+		for key := range s.cells {
+			visit(key)
+		}
+	} else {
+		for ring := 0; ring <= reach; ring++ {
+			if ring == 0 {
+				visit(centre)
+			}
+			for d := -ring; d < ring; d++ {
+				// each side of the ring, going round
+				visit(cellKey{centre.x + d, centre.y - ring})
+				visit(cellKey{centre.x + ring, centre.y + d})
+				visit(cellKey{centre.x - d, centre.y + ring})
+				visit(cellKey{centre.x - ring, centre.y - d})
+			}
+			// anything not seen yet is entirely outside this ring, so at least this far away
+			if len(found) >= k {
+				sortCandidates(found)
+				if found[k-1].distance <= float64(ring)*s.cellSize {
+					break
+				}
+			}
+		}
+	}
+	sortCandidates(found)
+	if len(found) > k {
+		found = found[:k]
+	}
+	actors := make([]*Actor, len(found))
+	for i, c := range found {
+		actors[i] = c.actor
+	}
+	return actors
+}
+
+type spatialCandidate struct {
+	actor    *Actor
+	distance float64
+}
+
+func sortCandidates(found []spatialCandidate) {
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].distance == found[j].distance {
+			return found[i].actor.actorId < found[j].actor.actorId
+		}
+		return found[i].distance < found[j].distance
+	})
+}
+
+// pairs gives every pair of actors that pass keep and share a cell, each once, lower actor id first.
+// everything else is dropped before pairing, so actors that can't be in a pair don't cost anything but a check
+func (s *spatialIndexImpl) pairs(keep func(actor *Actor) bool) []actorPair {
+	s.fitCellSize()
+	seen := make(map[actorPair]bool)
+	pairs := make([]actorPair, 0)
+	kept := make([]*Actor, 0)
+	for _, all := range s.cells {
+		cell := kept[:0]
+		for _, actor := range all {
+			if keep(actor) {
+				cell = append(cell, actor)
+			}
+		}
+		kept = cell
+		for i := 0; i < len(cell); i++ {
+			for j := i + 1; j < len(cell); j++ {
+				pairs = addPair(pairs, seen, cell[i], cell[j])
+			}
+		}
+	}
+	// oversized actors aren't in any cell, so they're paired with everything
+	for actor := range s.oversized {
+		if !keep(actor) {
+			continue
+		}
+		for other := range s.entries {
+			if other != actor && keep(other) {
+				pairs = addPair(pairs, seen, actor, other)
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0].actorId == pairs[j][0].actorId {
+			return pairs[i][1].actorId < pairs[j][1].actorId
+		}
+		return pairs[i][0].actorId < pairs[j][0].actorId
+	})
+	return pairs
+}
+
+func addPair(pairs []actorPair, seen map[actorPair]bool, a, b *Actor) []actorPair {
+	pair := actorPair{a, b}
+	if pair[0].actorId > pair[1].actorId {
+		pair[0], pair[1] = pair[1], pair[0]
+	}
+	if seen[pair] {
+		return pairs
+	}
+	seen[pair] = true
+	return append(pairs, pair)
+}
+
+// rectDistance is how far a point is from a rect, zero inside it
+func rectDistance(r Rect, p Vec2) float64 {
+	dx := math.Max(math.Max(r.Min.X-p.X, 0), p.X-r.Max.X)
+	dy := math.Max(math.Max(r.Min.Y-p.Y, 0), p.Y-r.Max.Y)
+	return math.Hypot(dx, dy)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// actorBounds is what the scene's index tracks an actor by: its collider, or failing that its (non raw) graphic,
+// or failing that just where its transform is. false without a transform
+func actorBounds(actor *Actor) (Rect, bool) {
+	if colliderComp, present := actor.GetComponentBySystemType(ComponentSystemCollider); present {
+		if bounds, ok := colliderComp.(ComponentCollider).Bounds(); ok {
+			return bounds, true
+		}
+	}
+	transformComp, present := actor.GetComponentBySystemType(ComponentSystemTransform)
+	if !present {
+		return Rect{}, false
+	}
+	transform := transformComp.(ComponentTransform)
+	pos, scale, rot := transform.Position(), transform.Scale(), transform.Rotation()
+	graphicalComp, present := actor.GetComponentBySystemType(ComponentSystemGraphical)
+	if !present {
+		return Rect{Min: pos, Max: pos}, true
+	}
+	graphical, ok := graphicalComp.(ComponentGraphical)
+	if !ok || graphical.Raw() {
+		return Rect{Min: pos, Max: pos}, true
+	}
+	// the same placement the graphics system draws with: turned about its top left corner
+	size := graphical.Size()
+	size.MultVec(scale)
+	topLeft := graphical.RelativePos().Sub(size.Scaled(0.5))
+	topLeft.Rotate(rot)
+	topLeft.Translate(pos)
+	bounds := Rect{Min: topLeft, Max: topLeft}
+	for _, corner := range []Vec2{{size.X, 0}, {size.X, size.Y}, {0, size.Y}} {
+		corner.Rotate(rot + graphical.Rotation())
+		corner.Translate(topLeft)
+		bounds = bounds.Union(Rect{Min: corner, Max: corner})
+	}
+	return bounds, true
+}
+
+// Spatial is the scene's index of every enabled actor with a transform. actors moved, scaled, turned or changed since
+// it was last asked are brought up to date before it answers, and it follows actors being added and removed
+func (s *Scene) Spatial() SpatialIndex { return sceneSpatialIndex{s} }
+
+// sceneSpatialIndex catches the scene's index up with whatever's moved before every call
+type sceneSpatialIndex struct {
+	scene *Scene
+}
+
+func (s sceneSpatialIndex) index() *spatialIndexImpl {
+	s.scene.flushSpatial()
+	return s.scene.spatial
+}
+
+func (s sceneSpatialIndex) CellSize() float64                { return s.scene.spatial.CellSize() }
+func (s sceneSpatialIndex) SetCellSize(size float64)         { s.index().SetCellSize(size) }
+func (s sceneSpatialIndex) Insert(actor *Actor, bounds Rect) { s.index().Insert(actor, bounds) }
+func (s sceneSpatialIndex) Update(actor *Actor, bounds Rect) { s.index().Update(actor, bounds) }
+func (s sceneSpatialIndex) Remove(actor *Actor)              { s.index().Remove(actor) }
+func (s sceneSpatialIndex) Bounds(actor *Actor) (Rect, bool) { return s.index().Bounds(actor) }
+func (s sceneSpatialIndex) Len() int                         { return s.index().Len() }
+func (s sceneSpatialIndex) QueryRect(r Rect) []*Actor        { return s.index().QueryRect(r) }
+func (s sceneSpatialIndex) QueryRadius(center Vec2, radius float64) []*Actor {
+	return s.index().QueryRadius(center, radius)
+}
+func (s sceneSpatialIndex) Nearest(point Vec2, k int) []*Actor { return s.index().Nearest(point, k) }
+
+// flushSpatial brings every actor that's moved since the last flush up to date, in the index and in the collision
+// system's gathered colliders (so queries between steps agree with it)
+func (s *Scene) flushSpatial() {
+	if len(s.spatialDirty) == 0 {
+		return
+	}
+	collision, _ := s.collisionSystem.(*collisionSystemImpl)
+	for actor := range s.spatialDirty {
+		delete(s.spatialDirty, actor)
+		if current, present := s.actors[actor.actorId]; !present || current != actor {
+			// moved after being taken out of the scene
+			continue
+		}
+		s.refreshSpatialActor(actor)
+		if collision != nil {
+			collision.refreshEntry(actor)
+		}
+	}
+}
+
+func (s *Scene) refreshSpatialActor(actor *Actor) {
+	if !actor.enabled {
+		s.spatial.Remove(actor)
+		return
+	}
+	if bounds, ok := actorBounds(actor); ok {
+		s.spatial.Update(actor, bounds)
+	} else {
+		s.spatial.Remove(actor)
+	}
+}
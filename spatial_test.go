@@ -0,0 +1,88 @@
+package nagae
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpatialUnboundedRects(t *testing.T) {
+	index := newSpatialIndex(1)
+	small, huge := NewActor("small"), NewActor("huge")
+	index.Insert(small, Rect{Min: Vec2{2, 2}, Max: Vec2{3, 3}})
+	index.Insert(huge, Rect{Min: Vec2{-1e10, -1e10}, Max: Vec2{1e10, 1e10}})
+
+	for _, r := range []Rect{
+		{Min: Vec2{-1e10, -1e10}, Max: Vec2{1e10, 1e10}},
+		{Min: Vec2{math.Inf(-1), math.Inf(-1)}, Max: Vec2{math.Inf(1), math.Inf(1)}},
+		{Min: Vec2{2.5, math.Inf(-1)}, Max: Vec2{2.5, math.Inf(1)}},
+	} {
+		if found := index.QueryRect(r); len(found) != 2 {
+			t.Errorf("query %v found %v, want both", r, found)
+		}
+	}
+	if found := index.QueryRect(Rect{Min: Vec2{-50, -50}, Max: Vec2{-49, -49}}); len(found) != 1 || found[0] != huge {
+		t.Errorf("query away from the small actor found %v, want just the huge one", found)
+	}
+	if found := index.Nearest(Vec2{1e12, 0}, 2); len(found) != 2 || found[0] != huge {
+		t.Errorf("nearest to a far point gave %v, want the huge one first", found)
+	}
+	if pairs := index.pairs(func(*Actor) bool { return true }); len(pairs) != 1 {
+		t.Errorf("got pairs %v, want the huge actor paired with the small one", pairs)
+	}
+
+	index.Remove(huge)
+	if found := index.QueryRect(Rect{Min: Vec2{-1e10, -1e10}, Max: Vec2{1e10, 1e10}}); len(found) != 1 || found[0] != small {
+		t.Errorf("after removing the huge actor found %v, want just the small one", found)
+	}
+}
+
+func TestRaycastInfiniteDistance(t *testing.T) {
+	scene := NewScene("test")
+	wall, err := NewComponentAABBCollider(Vec2{1, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addTestCollider(t, scene, "wall", Vec2{0, 50}, wall, BodyStatic)
+	if err := scene.Init(); err != nil {
+		t.Fatal(err)
+	}
+	step(t, scene, 1.0/60, 1)
+
+	hit, found := scene.Raycast(Vec2{}, Vec2{0, 1}, math.Inf(1), QueryFilter{})
+	if !found || hit.Actor.actorId != "wall" || math.Abs(hit.Distance-49.5) > 1e-9 {
+		t.Errorf("infinite ray down got %+v (hit %v), want the wall 49.5 away", hit, found)
+	}
+}
+
+func TestSpatialFitsCellSize(t *testing.T) {
+	// sized in pixels, the default cell size of 1 would put each of these in ten thousand cells
+	scene := NewScene("test")
+	for i, id := range []ActorId{"a", "b", "c", "d"} {
+		box, err := NewComponentAABBCollider(Vec2{100, 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		addTestCollider(t, scene, id, Vec2{float64(i) * 150, 0}, box, BodyStatic)
+	}
+	if err := scene.Init(); err != nil {
+		t.Fatal(err)
+	}
+	step(t, scene, 1.0/60, 1)
+
+	if size := scene.Spatial().CellSize(); size != 256 {
+		t.Errorf("cell size %v, want 256 for 100 wide boxes", size)
+	}
+	if cells, oversized := len(scene.spatial.cells), len(scene.spatial.oversized); cells > 16 || oversized > 0 {
+		t.Errorf("boxes cover %v cells with %v oversized, want a handful of cells", cells, oversized)
+	}
+	if found := scene.Spatial().QueryRect(Rect{Min: Vec2{140, -10}, Max: Vec2{160, 10}}); len(found) != 1 || found[0].actorId != "b" {
+		t.Errorf("query found %v, want b", found)
+	}
+
+	// given a size, it keeps it
+	scene.Spatial().SetCellSize(10)
+	step(t, scene, 1.0/60, 1)
+	if size := scene.Spatial().CellSize(); size != 10 {
+		t.Errorf("cell size %v after setting it to 10", size)
+	}
+}
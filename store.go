@@ -0,0 +1,243 @@
+package nagae
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// UserConfigPath is where an app keeps a file in the user's config directory
+func UserConfigPath(appName, fileName string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, appName, fileName), nil
+}
+
+// SharedDataCallback is called when a subscribed key changes. new is nil if the key was deleted
+type SharedDataCallback func(namespace, key string, old, new interface{})
+
+type sharedSubscription struct {
+	key      string
+	all      bool
+	callback SharedDataCallback
+}
+
+// SharedStore holds data shared between scenes, split into namespaces per subsystem
+type SharedStore struct {
+	namespaces map[string]*SharedNamespace
+	nextSubId  SubscriptionId
+}
+
+func NewSharedStore() *SharedStore {
+	return &SharedStore{
+		namespaces: make(map[string]*SharedNamespace),
+	}
+}
+
+// Namespace gets (or creates) a namespace
+func (s *SharedStore) Namespace(name string) *SharedNamespace {
+	if namespace, present := s.namespaces[name]; present {
+		return namespace
+	}
+	namespace := &SharedNamespace{
+		store:         s,
+		name:          name,
+		values:        make(map[string]interface{}),
+		persistent:    make(map[string]bool),
+		subscriptions: make(map[SubscriptionId]sharedSubscription),
+	}
+	s.namespaces[name] = namespace
+	return namespace
+}
+
+func (s SharedStore) Namespaces() []string {
+	names := make([]string, 0, len(s.namespaces))
+	for name := range s.namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Unsubscribe removes a subscription from whatever namespace it's in
+func (s *SharedStore) Unsubscribe(id SubscriptionId) bool {
+	for _, namespace := range s.namespaces {
+		if _, present := namespace.subscriptions[id]; present {
+			delete(namespace.subscriptions, id)
+			return true
+		}
+	}
+	return false
+}
+
+// Save writes every persistent key to a json file
+func (s SharedStore) Save(path string) error {
+	saved := make(map[string]map[string]interface{})
+	for name, namespace := range s.namespaces {
+		for key := range namespace.persistent {
+			value, present := namespace.values[key]
+			if !present {
+				continue
+			}
+			if _, present := saved[name]; !present {
+				saved[name] = make(map[string]interface{})
+			}
+			saved[name][key] = value
+		}
+	}
+	data, err := json.MarshalIndent(saved, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Load reads a save file, putting (and marking persistent) every key in it.
+// a missing file isn't an error, there just isn't anything saved yet
+func (s *SharedStore) Load(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	loaded := make(map[string]map[string]interface{})
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return err
+	}
+	for name, values := range loaded {
+		namespace := s.Namespace(name)
+		for key, value := range values {
+			namespace.MarkPersistent(key, true)
+			namespace.Put(key, value)
+		}
+	}
+	return nil
+}
+
+// SharedNamespace is one subsystem's section of the shared store
+type SharedNamespace struct {
+	store *SharedStore
+	name  string
+
+	values        map[string]interface{}
+	persistent    map[string]bool
+	subscriptions map[SubscriptionId]sharedSubscription
+}
+
+func (n SharedNamespace) Name() string { return n.name }
+
+func (n SharedNamespace) Keys() []string {
+	keys := make([]string, 0, len(n.values))
+	for key := range n.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (n SharedNamespace) Get(key string) (interface{}, bool) {
+	value, present := n.values[key]
+	return value, present
+}
+
+// GetInt accepts any numeric value, since anything loaded from a save file comes back as a float
+func (n SharedNamespace) GetInt(key string) (int, bool) {
+	switch value := n.values[key].(type) {
+	case int:
+		return value, true
+	case int64:
+		return int(value), true
+	case float64:
+		return int(value), true
+	}
+	return 0, false
+}
+
+func (n SharedNamespace) GetFloat(key string) (float64, bool) {
+	switch value := n.values[key].(type) {
+	case float64:
+		return value, true
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	}
+	return 0, false
+}
+
+func (n SharedNamespace) GetString(key string) (string, bool) {
+	value, ok := n.values[key].(string)
+	return value, ok
+}
+
+func (n SharedNamespace) GetBool(key string) (bool, bool) {
+	value, ok := n.values[key].(bool)
+	return value, ok
+}
+
+func (n *SharedNamespace) Put(key string, value interface{}) {
+	old := n.values[key]
+	n.values[key] = value
+	n.notify(key, old, value)
+}
+
+func (n *SharedNamespace) Delete(key string) bool {
+	old, present := n.values[key]
+	if !present {
+		return false
+	}
+	delete(n.values, key)
+	n.notify(key, old, nil)
+	return true
+}
+
+// MarkPersistent sets whether a key gets written to the save file
+func (n *SharedNamespace) MarkPersistent(key string, persistent bool) {
+	if persistent {
+		n.persistent[key] = true
+	} else {
+		delete(n.persistent, key)
+	}
+}
+
+func (n SharedNamespace) Persistent(key string) bool { return n.persistent[key] }
+
+// Subscribe calls back whenever the key is put or deleted
+func (n *SharedNamespace) Subscribe(key string, callback SharedDataCallback) SubscriptionId {
+	return n.subscribe(sharedSubscription{key: key, callback: callback})
+}
+
+// SubscribeAll calls back whenever any key in the namespace changes
+func (n *SharedNamespace) SubscribeAll(callback SharedDataCallback) SubscriptionId {
+	return n.subscribe(sharedSubscription{all: true, callback: callback})
+}
+
+func (n *SharedNamespace) subscribe(subscription sharedSubscription) SubscriptionId {
+	n.store.nextSubId++
+	n.subscriptions[n.store.nextSubId] = subscription
+	return n.store.nextSubId
+}
+
+func (n *SharedNamespace) notify(key string, old, new interface{}) {
+	ids := make([]SubscriptionId, 0, len(n.subscriptions))
+	for id := range n.subscriptions {
+		ids = append(ids, id)
+	}
+	// keep callbacks in the order they subscribed
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		subscription, present := n.subscriptions[id]
+		if !present || (!subscription.all && subscription.key != key) {
+			continue
+		}
+		subscription.callback(n.name, key, old, new)
+	}
+}
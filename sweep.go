@@ -0,0 +1,88 @@
+package nagae
+
+import "math"
+
+const sweepBisections = 12
+
+func (c convexShape) translated(delta Vec2) convexShape {
+	points := make([]Vec2, len(c.points))
+	for i, p := range c.points {
+		points[i] = p.Add(delta)
+	}
+	return convexShape{points: points, radius: c.radius}
+}
+
+// minExtent is roughly the thinnest the shape gets, used to size sweep steps so nothing is skipped
+func (c convexShape) minExtent() float64 {
+	size := c.bounds().Size()
+	return math.Max(math.Min(size.X, size.Y), 1e-3)
+}
+
+// sweepHit is the first thing a swept shape ran into
+type sweepHit struct {
+	fraction float64 // how far along the motion the shape can safely get
+	entry    colliderEntry
+	manifold ContactManifold // normal points from the swept shape into what it hit
+}
+
+// sweepFilter can throw away a hit (ie one way platforms). it gets the contact a bit past the time of impact
+type sweepFilter func(entry colliderEntry, manifold ContactManifold) bool
+
+// sweepShape moves a shape along delta and finds the time of impact against the others.
+// it steps in pieces smaller than the shape so thin colliders can't be skipped, then bisects the step that hit.
+// anything already overlapping at the start is ignored unless the motion goes further into it
+func sweepShape(shape convexShape, delta Vec2, others []colliderEntry, filter sweepFilter) (sweepHit, bool) {
+	distance := delta.Hypot()
+	if distance == 0 {
+		return sweepHit{}, false
+	}
+	blocking := func(entry colliderEntry, manifold ContactManifold) bool {
+		return filter == nil || filter(entry, manifold)
+	}
+
+	candidates := make([]colliderEntry, 0, len(others))
+	for _, entry := range others {
+		if manifold, hit := collideShapes(shape, entry.shape); hit && manifold.Normal.Dot(delta) <= 0 {
+			continue
+		}
+		candidates = append(candidates, entry)
+	}
+
+	firstHit := func(fraction float64) (colliderEntry, ContactManifold, bool) {
+		moved := shape.translated(delta.Scaled(fraction))
+		bestDepth := -1.0
+		var bestEntry colliderEntry
+		var bestManifold ContactManifold
+		for _, entry := range candidates {
+			manifold, hit := collideShapes(moved, entry.shape)
+			if !hit || !blocking(entry, manifold) {
+				continue
+			}
+			if manifold.Depth > bestDepth {
+				bestDepth, bestEntry, bestManifold = manifold.Depth, entry, manifold
+			}
+		}
+		return bestEntry, bestManifold, bestDepth >= 0
+	}
+
+	steps := int(math.Ceil(distance / (shape.minExtent() / 2)))
+	lo := 0.0
+	for i := 1; i <= steps; i++ {
+		hi := float64(i) / float64(steps)
+		entry, manifold, hit := firstHit(hi)
+		if !hit {
+			lo = hi
+			continue
+		}
+		for j := 0; j < sweepBisections; j++ {
+			mid := (lo + hi) / 2
+			if midEntry, midManifold, midHit := firstHit(mid); midHit {
+				hi, entry, manifold = mid, midEntry, midManifold
+			} else {
+				lo = mid
+			}
+		}
+		return sweepHit{fraction: lo, entry: entry, manifold: manifold}, true
+	}
+	return sweepHit{}, false
+}
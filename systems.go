@@ -18,13 +18,43 @@ type systemImpl struct {
 func (s systemImpl) Init() error             { return nil }
 func (s systemImpl) Update(dt float64) error { return nil }
 
-// PhysicsSystem handles updating physics objects (purely forces and velocities)
+// PhysicsSystem handles updating physics objects (forces, velocities and spin) and the joints between them
 type PhysicsSystem interface {
 	System
+
+	Integrator() Integrator
+	SetIntegrator(integrator Integrator)
+
+	Joints() []Joint
+	AddJoint(joint Joint) error
+	RemoveJoint(joint Joint) bool
+	JointIterations() int // position passes over all joints each step
+	SetJointIterations(iterations int)
+
+	SleepEnabled() bool
+	SetSleepEnabled(enabled bool)
+	TimeToSleep() float64 // how long an island has to be still before it sleeps
+	SetTimeToSleep(seconds float64)
+	SleepThresholds() (float64, float64) // speed and spin below which a body counts as still
+	SetSleepThresholds(linear, angular float64)
+	Stats() PhysicsStats
 }
 
 type physicsSystemImpl struct {
 	systemImpl
+	integrator Integrator
+
+	joints          []*jointImpl
+	jointIterations int
+
+	swept bool // the collision system has gathered colliders for continuous bodies this step
+
+	sleepEnabled bool
+	timeToSleep  float64
+	sleepLinear  float64
+	sleepAngular float64
+	lastIsland   uint64
+	stats        PhysicsStats
 }
 
 func NewPhysicsSystem(scene *Scene) PhysicsSystem {
@@ -32,11 +62,27 @@ func NewPhysicsSystem(scene *Scene) PhysicsSystem {
 		systemImpl: systemImpl{
 			attachedScene: scene,
 		},
+		integrator:      IntegratorSemiImplicitEuler,
+		joints:          make([]*jointImpl, 0),
+		jointIterations: 8,
+		sleepEnabled:    true,
+		timeToSleep:     0.5,
+		sleepLinear:     0.05,
+		sleepAngular:    0.05,
 	}
 }
 
+func (p physicsSystemImpl) Integrator() Integrator               { return p.integrator }
+func (p *physicsSystemImpl) SetIntegrator(integrator Integrator) { p.integrator = integrator }
+
 func (p *physicsSystemImpl) Update(dt float64) error {
+	p.updateSleep(dt)
+	p.prepareJoints()
+	p.swept = false
 	for _, actor := range p.attachedScene.actors {
+		if !actor.enabled {
+			continue
+		}
 		physicsComp, present := actor.GetComponentBySystemType(ComponentSystemPhysics)
 		if !present {
 			continue
@@ -46,23 +92,53 @@ func (p *physicsSystemImpl) Update(dt float64) error {
 			continue
 		}
 
-		// update velocity based on acceleration
 		physicsCompImpl := physicsComp.(*componentPhysicsImpl)
 		transformCompImpl := transformComp.(*componentTransformImpl)
 
-		physicsCompImpl.frameAcceleration.MultScalar(dt)
-		physicsCompImpl.velocity.Translate(physicsCompImpl.frameAcceleration)
-		physicsCompImpl.frameAcceleration = Vec2{0, 0}
+		if _, present := actor.GetComponentByType(ComponentTypeCharacterController); present {
+			// character controllers do their own moving
+			physicsCompImpl.frameAcceleration = Vec2{0, 0}
+			physicsCompImpl.frameTorque = 0
+			continue
+		}
 
-		// NOTE we don't care about collisions or friction in this system.
-		// those will be handled by other systems that then apply forces to our physics body
+		switch physicsCompImpl.bodyType {
+		case BodyStatic:
+			physicsCompImpl.frameAcceleration = Vec2{0, 0}
+			physicsCompImpl.frameTorque = 0
+			continue
+		case BodyKinematic:
+			// kinematic bodies ignore forces and just move
+			physicsCompImpl.frameAcceleration = Vec2{0, 0}
+			physicsCompImpl.frameTorque = 0
+			transformCompImpl.pos.Translate(physicsCompImpl.velocity.Scaled(dt))
+			transformCompImpl.rotation += physicsCompImpl.angularVelocity * dt
+			actor.moved()
+			continue
+		}
+
+		if physicsCompImpl.sleeping {
+			continue
+		}
+		integrateRotation(p.integrator, physicsCompImpl, transformCompImpl, dt)
 
-		// update position based on velocity
-		vel := physicsCompImpl.velocity
-		vel.MultScalar(dt)
-		transformCompImpl.pos.Translate(vel)
+		delta, velocity := integrate(p.integrator, physicsCompImpl.velocity, bodyAcceleration(physicsCompImpl), dt)
+		physicsCompImpl.velocity = clampVelocity(velocity, physicsCompImpl.maxVelocity)
+		// the step itself can't go faster than the limit either
+		delta = clampVelocity(delta, physicsCompImpl.maxVelocity*dt)
+		physicsCompImpl.frameAcceleration = Vec2{0, 0}
+		if physicsCompImpl.continuous {
+			delta = p.sweepBody(actor, delta)
+		}
+		transformCompImpl.pos.Translate(delta)
+		actor.moved()
+
+		// NOTE contacts are resolved by the collision system, which runs after this
 	}
-	return nil
+	if dt <= 0 {
+		return nil
+	}
+	return p.solveJoints(dt)
 }
 
 // GraphicsSystem handles drawing all components to the screen -- updating animation controllers is handled by the overarching system
@@ -89,6 +165,9 @@ func (g *graphicsSystemImpl) Draw(screen *ebiten.Image) error {
 	drawOrders := make(map[int][]DrawCall)
 	drawLayers := make([]int, 0)
 	for _, actor := range g.attachedScene.actors {
+		if !actor.enabled {
+			continue
+		}
 		graphicalComp, present := actor.GetComponentBySystemType(ComponentSystemGraphical)
 		if !present {
 			continue
@@ -0,0 +1,501 @@
+package nagae
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// TouchSource is where raw touches come from. the synthetic source lets you script touch streams
+type TouchSource interface {
+	TouchIDs() []int
+	TouchPosition(id int) (int, int)
+}
+
+type ebitenTouchSource struct{}
+
+func (e ebitenTouchSource) TouchIDs() []int                 { return ebiten.TouchIDs() }
+func (e ebitenTouchSource) TouchPosition(id int) (int, int) { return ebiten.TouchPosition(id) }
+
+func NewEbitenTouchSource() TouchSource { return ebitenTouchSource{} }
+
+// SyntheticTouchSource is a touch source driven by hand. useful for tests and replays
+type SyntheticTouchSource struct {
+	touches map[int]image.Point
+}
+
+func NewSyntheticTouchSource() *SyntheticTouchSource {
+	return &SyntheticTouchSource{touches: make(map[int]image.Point)}
+}
+
+func (s *SyntheticTouchSource) Press(id, x, y int) { s.touches[id] = image.Point{x, y} }
+func (s *SyntheticTouchSource) Move(id, x, y int)  { s.touches[id] = image.Point{x, y} }
+func (s *SyntheticTouchSource) Release(id int)     { delete(s.touches, id) }
+
+func (s SyntheticTouchSource) TouchIDs() []int {
+	ids := make([]int, 0, len(s.touches))
+	for id := range s.touches {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func (s SyntheticTouchSource) TouchPosition(id int) (int, int) {
+	p := s.touches[id]
+	return p.X, p.Y
+}
+
+// GestureType is what kind of gesture was recognized
+type GestureType uint8
+
+const (
+	GestureTap GestureType = iota
+	GestureDoubleTap
+	GestureLongPress
+	GestureSwipe
+	GesturePinch
+)
+
+// Gesture is a recognized gesture. positions are in screen pixels, World is the same point in world units
+type Gesture struct {
+	Type     GestureType
+	Position Vec2
+	World    Vec2
+	Delta    Vec2    // swipe distance in pixels
+	Scale    float64 // pinch scale since the last frame
+	Duration float64
+}
+
+// TouchPoint is a touch that's currently down
+type TouchPoint struct {
+	Id          int
+	Position    Vec2
+	Start       Vec2
+	Duration    float64
+	JustPressed bool
+	Claimed     bool
+}
+
+type touchTrack struct {
+	TouchPoint
+	previous    Vec2
+	seen        bool
+	longPressed bool
+	pinched     bool
+}
+
+// TouchConfig holds gesture thresholds. distances are in pixels and times in seconds
+type TouchConfig struct {
+	TapMaxDuration    float64
+	TapMaxMovement    float64
+	DoubleTapWindow   float64
+	DoubleTapDistance float64
+	LongPressDuration float64
+	SwipeMinDistance  float64
+	SwipeMaxDuration  float64
+}
+
+func DefaultTouchConfig() TouchConfig {
+	return TouchConfig{
+		TapMaxDuration:    0.25,
+		TapMaxMovement:    10,
+		DoubleTapWindow:   0.3,
+		DoubleTapDistance: 30,
+		LongPressDuration: 0.5,
+		SwipeMinDistance:  50,
+		SwipeMaxDuration:  0.5,
+	}
+}
+
+// TouchInput tracks raw touches and turns them into gestures
+type TouchInput struct {
+	source TouchSource
+	config TouchConfig
+
+	time     float64
+	tracks   map[int]*touchTrack
+	released []*touchTrack // let go since the last poll, waiting to be recognized
+	gestures []Gesture
+
+	lastTapTime float64
+	lastTapPos  Vec2
+	hasLastTap  bool
+}
+
+func NewTouchInput() *TouchInput {
+	return &TouchInput{
+		source:   NewEbitenTouchSource(),
+		config:   DefaultTouchConfig(),
+		tracks:   make(map[int]*touchTrack),
+		gestures: make([]Gesture, 0),
+	}
+}
+
+func (t TouchInput) Source() TouchSource           { return t.source }
+func (t *TouchInput) SetSource(source TouchSource) { t.source = source }
+func (t TouchInput) Config() TouchConfig           { return t.config }
+func (t *TouchInput) SetConfig(config TouchConfig) { t.config = config }
+
+// Gestures recognized during the last update
+func (t TouchInput) Gestures() []Gesture { return t.gestures }
+
+func (t TouchInput) Touches() []TouchPoint {
+	points := make([]TouchPoint, 0, len(t.tracks))
+	for _, track := range t.tracks {
+		points = append(points, track.TouchPoint)
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Id < points[j].Id })
+	return points
+}
+
+// Claim stops a touch from producing gestures (ie it's being used by an on screen control)
+func (t *TouchInput) Claim(id int) bool {
+	track, present := t.tracks[id]
+	if !present {
+		return false
+	}
+	track.Claimed = true
+	return true
+}
+
+func (t *TouchInput) emit(gesture Gesture) {
+	gesture.World = ScreenToWorld(gesture.Position.X, gesture.Position.Y)
+	t.gestures = append(t.gestures, gesture)
+}
+
+// Update is Poll then Recognize. the scene manager runs them separately, so its virtual overlay can claim touches
+// in between
+func (t *TouchInput) Update(dt float64) {
+	t.Poll(dt)
+	t.Recognize()
+}
+
+// Poll reads the touches from the source. touches that were let go are dropped, but still recognized as taps and
+// swipes by the next Recognize
+func (t *TouchInput) Poll(dt float64) {
+	t.time += dt
+	t.gestures = t.gestures[:0]
+	t.released = t.released[:0]
+
+	for _, track := range t.tracks {
+		track.seen = false
+		track.JustPressed = false
+	}
+	for _, id := range t.source.TouchIDs() {
+		x, y := t.source.TouchPosition(id)
+		pos := Vec2{float64(x), float64(y)}
+		track, present := t.tracks[id]
+		if !present {
+			track = &touchTrack{TouchPoint: TouchPoint{Id: id, Start: pos, Position: pos, JustPressed: true}, previous: pos}
+			t.tracks[id] = track
+		} else {
+			track.previous = track.Position
+			track.Position = pos
+			track.Duration += dt
+		}
+		track.seen = true
+	}
+
+	for _, id := range t.trackIds() {
+		if track := t.tracks[id]; !track.seen {
+			t.released = append(t.released, track)
+			delete(t.tracks, id)
+		}
+	}
+}
+
+// Recognize turns the polled touches into gestures, leaving out any that were claimed since
+func (t *TouchInput) Recognize() {
+	t.updatePinch()
+
+	for _, track := range t.released {
+		t.release(track)
+	}
+	t.released = t.released[:0]
+	for _, id := range t.trackIds() {
+		track := t.tracks[id]
+		if track.Claimed || track.longPressed || track.pinched {
+			continue
+		}
+		if track.Duration >= t.config.LongPressDuration && touchMovement(track) <= t.config.TapMaxMovement {
+			track.longPressed = true
+			t.emit(Gesture{Type: GestureLongPress, Position: track.Position, Duration: track.Duration})
+		}
+	}
+}
+
+func (t TouchInput) trackIds() []int {
+	ids := make([]int, 0, len(t.tracks))
+	for id := range t.tracks {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func touchMovement(track *touchTrack) float64 {
+	delta := track.Position
+	delta.Translate(Vec2{-track.Start.X, -track.Start.Y})
+	return delta.Hypot()
+}
+
+func (t *TouchInput) release(track *touchTrack) {
+	if track.Claimed || track.longPressed || track.pinched {
+		return
+	}
+	moved := touchMovement(track)
+	if track.Duration <= t.config.TapMaxDuration && moved <= t.config.TapMaxMovement {
+		fromLast := track.Position
+		fromLast.Translate(Vec2{-t.lastTapPos.X, -t.lastTapPos.Y})
+		if t.hasLastTap && t.time-t.lastTapTime <= t.config.DoubleTapWindow && fromLast.Hypot() <= t.config.DoubleTapDistance {
+			t.hasLastTap = false
+			t.emit(Gesture{Type: GestureDoubleTap, Position: track.Position, Duration: track.Duration})
+			return
+		}
+		t.hasLastTap = true
+		t.lastTapTime = t.time
+		t.lastTapPos = track.Position
+		t.emit(Gesture{Type: GestureTap, Position: track.Position, Duration: track.Duration})
+	} else if moved >= t.config.SwipeMinDistance && track.Duration <= t.config.SwipeMaxDuration {
+		delta := track.Position
+		delta.Translate(Vec2{-track.Start.X, -track.Start.Y})
+		t.emit(Gesture{Type: GestureSwipe, Position: track.Start, Delta: delta, Duration: track.Duration})
+	}
+}
+
+// updatePinch turns exactly two free touches into a pinch
+func (t *TouchInput) updatePinch() {
+	free := make([]*touchTrack, 0, 2)
+	for _, track := range t.tracks {
+		if !track.Claimed {
+			free = append(free, track)
+		}
+	}
+	if len(free) != 2 {
+		return
+	}
+	a, b := free[0], free[1]
+	a.pinched, b.pinched = true, true
+	if a.JustPressed || b.JustPressed {
+		return
+	}
+	prevDist := math.Hypot(a.previous.X-b.previous.X, a.previous.Y-b.previous.Y)
+	dist := math.Hypot(a.Position.X-b.Position.X, a.Position.Y-b.Position.Y)
+	if prevDist == 0 || dist == prevDist {
+		return
+	}
+	center := Vec2{(a.Position.X + b.Position.X) / 2, (a.Position.Y + b.Position.Y) / 2}
+	t.emit(Gesture{Type: GesturePinch, Position: center, Scale: dist / prevDist})
+}
+
+// VirtualJoystick is an on screen stick that presses directional actions. positions are in screen pixels
+type VirtualJoystick struct {
+	Center   Vec2
+	Radius   float64
+	DeadZone float64 // fraction of the radius before a direction counts as pressed
+
+	Up, Down, Left, Right ActionId
+
+	touchId int
+	active  bool
+	axis    Vec2
+}
+
+func NewVirtualJoystick(center Vec2, radius float64, up, down, left, right ActionId) *VirtualJoystick {
+	return &VirtualJoystick{
+		Center:   center,
+		Radius:   radius,
+		DeadZone: 0.3,
+		Up:       up,
+		Down:     down,
+		Left:     left,
+		Right:    right,
+	}
+}
+
+// Axis is the stick position, each component in [-1, 1]
+func (j VirtualJoystick) Axis() Vec2   { return j.axis }
+func (j VirtualJoystick) Active() bool { return j.active }
+
+// VirtualButton is an on screen button that presses an action
+type VirtualButton struct {
+	Center Vec2
+	Radius float64
+	Action ActionId
+
+	touchId int
+	pressed bool
+}
+
+func NewVirtualButton(center Vec2, radius float64, action ActionId) *VirtualButton {
+	return &VirtualButton{Center: center, Radius: radius, Action: action}
+}
+
+func (b VirtualButton) Pressed() bool { return b.pressed }
+
+func insideCircle(p, center Vec2, radius float64) bool {
+	return math.Hypot(p.X-center.X, p.Y-center.Y) <= radius
+}
+
+// VirtualOverlay is a set of on screen controls feeding an action map
+type VirtualOverlay struct {
+	actions   *ActionMap
+	joysticks []*VirtualJoystick
+	buttons   []*VirtualButton
+
+	Color       color.Color
+	ActiveColor color.Color
+	circles     map[int]*ebiten.Image
+}
+
+func NewVirtualOverlay(actions *ActionMap) *VirtualOverlay {
+	return &VirtualOverlay{
+		actions:     actions,
+		joysticks:   make([]*VirtualJoystick, 0),
+		buttons:     make([]*VirtualButton, 0),
+		Color:       color.RGBA{255, 255, 255, 64},
+		ActiveColor: color.RGBA{255, 255, 255, 128},
+		circles:     make(map[int]*ebiten.Image),
+	}
+}
+
+func (v *VirtualOverlay) AddJoystick(joystick *VirtualJoystick) {
+	v.joysticks = append(v.joysticks, joystick)
+}
+func (v *VirtualOverlay) AddButton(button *VirtualButton) { v.buttons = append(v.buttons, button) }
+
+func (v *VirtualOverlay) Update(touch *TouchInput) {
+	touches := touch.Touches()
+	// controls can share an action, so it's held if any of them hold it
+	pressed := make(map[ActionId]bool)
+	press := func(action ActionId, down bool) { pressed[action] = pressed[action] || down }
+	find := func(id int) (TouchPoint, bool) {
+		for _, point := range touches {
+			if point.Id == id {
+				return point, true
+			}
+		}
+		return TouchPoint{}, false
+	}
+
+	for _, joystick := range v.joysticks {
+		if joystick.active {
+			if _, present := find(joystick.touchId); !present {
+				joystick.active = false
+			}
+		}
+		if !joystick.active {
+			for _, point := range touches {
+				if point.JustPressed && !point.Claimed && insideCircle(point.Position, joystick.Center, joystick.Radius) {
+					joystick.active = true
+					joystick.touchId = point.Id
+					touch.Claim(point.Id)
+					break
+				}
+			}
+		}
+		joystick.axis = Vec2{}
+		if joystick.active {
+			point, _ := find(joystick.touchId)
+			delta := point.Position
+			delta.Translate(Vec2{-joystick.Center.X, -joystick.Center.Y})
+			if dist := delta.Hypot(); dist > joystick.Radius {
+				delta.MultScalar(joystick.Radius / dist)
+			}
+			delta.MultScalar(1 / joystick.Radius)
+			joystick.axis = delta
+		}
+		press(joystick.Up, joystick.axis.Y < -joystick.DeadZone)
+		press(joystick.Down, joystick.axis.Y > joystick.DeadZone)
+		press(joystick.Left, joystick.axis.X < -joystick.DeadZone)
+		press(joystick.Right, joystick.axis.X > joystick.DeadZone)
+	}
+
+	for _, button := range v.buttons {
+		if button.pressed {
+			if _, present := find(button.touchId); !present {
+				button.pressed = false
+			}
+		}
+		if !button.pressed {
+			for _, point := range touches {
+				if point.JustPressed && !point.Claimed && insideCircle(point.Position, button.Center, button.Radius) {
+					button.pressed = true
+					button.touchId = point.Id
+					touch.Claim(point.Id)
+					break
+				}
+			}
+		}
+		press(button.Action, button.pressed)
+	}
+	for action, down := range pressed {
+		v.actions.SetVirtualPressed(action, down)
+	}
+}
+
+func (v *VirtualOverlay) circle(radius int) (*ebiten.Image, error) {
+	if img, present := v.circles[radius]; present {
+		return img, nil
+	}
+	size := radius*2 + 1
+	rgba := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if math.Hypot(float64(x-radius), float64(y-radius)) <= float64(radius) {
+				rgba.Set(x, y, color.White)
+			}
+		}
+	}
+	img, err := ebiten.NewImageFromImage(rgba, ebiten.FilterDefault)
+	if err != nil {
+		return nil, err
+	}
+	v.circles[radius] = img
+	return img, nil
+}
+
+func (v *VirtualOverlay) drawCircle(screen *ebiten.Image, center Vec2, radius float64, clr color.Color) error {
+	img, err := v.circle(int(radius))
+	if err != nil {
+		return err
+	}
+	drawOptions := ebiten.DrawImageOptions{}
+	drawOptions.GeoM.Translate(center.X-float64(int(radius)), center.Y-float64(int(radius)))
+	r, g, b, a := clr.RGBA()
+	drawOptions.ColorM.Scale(float64(r)/0xffff, float64(g)/0xffff, float64(b)/0xffff, float64(a)/0xffff)
+	return screen.DrawImage(img, &drawOptions)
+}
+
+// Draw renders the controls in screen space, on top of whatever is there
+func (v *VirtualOverlay) Draw(screen *ebiten.Image) error {
+	for _, joystick := range v.joysticks {
+		if err := v.drawCircle(screen, joystick.Center, joystick.Radius, v.Color); err != nil {
+			return err
+		}
+		knob := joystick.axis
+		knob.MultScalar(joystick.Radius)
+		knob.Translate(joystick.Center)
+		knobColor := v.Color
+		if joystick.active {
+			knobColor = v.ActiveColor
+		}
+		if err := v.drawCircle(screen, knob, joystick.Radius/2, knobColor); err != nil {
+			return err
+		}
+	}
+	for _, button := range v.buttons {
+		buttonColor := v.Color
+		if button.pressed {
+			buttonColor = v.ActiveColor
+		}
+		if err := v.drawCircle(screen, button.Center, button.Radius, buttonColor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
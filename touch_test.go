@@ -0,0 +1,49 @@
+package nagae
+
+import "testing"
+
+func TestOverlayClaimsBeforePinch(t *testing.T) {
+	const dt = 1.0 / 60
+	manager := NewSceneManager(NewScene("test"))
+	manager.Actions().SetSource(fakeInputSource{})
+	manager.Actions().DefineAction("jump")
+	touches := NewSyntheticTouchSource()
+	manager.Touch().SetSource(touches)
+	overlay := NewVirtualOverlay(manager.Actions())
+	button := NewVirtualButton(Vec2{50, 50}, 30, "jump")
+	overlay.AddButton(button)
+	manager.SetVirtualOverlay(overlay)
+	update := func() {
+		t.Helper()
+		if err := manager.Update(dt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a finger taps the screen while a thumb lands on the button, which isn't a pinch
+	touches.Press(1, 300, 300)
+	update()
+	touches.Press(2, 50, 50)
+	update()
+	if !button.Pressed() {
+		t.Fatal("button wasn't pressed")
+	}
+	touches.Release(1)
+	update()
+
+	gestures := manager.Touch().Gestures()
+	if len(gestures) != 1 || gestures[0].Type != GestureTap || gestures[0].Position != (Vec2{300, 300}) {
+		t.Errorf("got gestures %+v, want a tap at (300, 300)", gestures)
+	}
+
+	// two free fingers still pinch
+	touches.Press(3, 200, 200)
+	touches.Press(4, 300, 200)
+	update()
+	touches.Move(4, 400, 200)
+	update()
+	gestures = manager.Touch().Gestures()
+	if len(gestures) != 1 || gestures[0].Type != GesturePinch || gestures[0].Scale != 2 {
+		t.Errorf("got gestures %+v, want a pinch scaling by 2", gestures)
+	}
+}
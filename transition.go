@@ -0,0 +1,166 @@
+package nagae
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// SceneTransition renders the switch between two scenes. from and to are full screen renders of each scene
+type SceneTransition interface {
+	Duration() float64
+	Draw(screen, from, to *ebiten.Image, progress float64) error
+}
+
+func colorScale(options *ebiten.DrawImageOptions, alpha float64) {
+	options.ColorM.Scale(1, 1, 1, alpha)
+}
+
+type fadeTransition struct {
+	duration float64
+	color    color.Color
+}
+
+// NewFadeTransition fades out to a color, then fades in the new scene
+func NewFadeTransition(duration float64, clr color.Color) SceneTransition {
+	return &fadeTransition{duration: duration, color: clr}
+}
+
+func (f fadeTransition) Duration() float64 { return f.duration }
+
+func (f fadeTransition) Draw(screen, from, to *ebiten.Image, progress float64) error {
+	if err := screen.Fill(f.color); err != nil {
+		return err
+	}
+	drawOptions := ebiten.DrawImageOptions{}
+	if progress < 0.5 {
+		colorScale(&drawOptions, 1-progress*2)
+		return screen.DrawImage(from, &drawOptions)
+	}
+	colorScale(&drawOptions, progress*2-1)
+	return screen.DrawImage(to, &drawOptions)
+}
+
+type crossfadeTransition struct {
+	duration float64
+}
+
+// NewCrossfadeTransition blends the old scene into the new one
+func NewCrossfadeTransition(duration float64) SceneTransition {
+	return &crossfadeTransition{duration: duration}
+}
+
+func (c crossfadeTransition) Duration() float64 { return c.duration }
+
+func (c crossfadeTransition) Draw(screen, from, to *ebiten.Image, progress float64) error {
+	if err := screen.DrawImage(from, &ebiten.DrawImageOptions{}); err != nil {
+		return err
+	}
+	drawOptions := ebiten.DrawImageOptions{}
+	colorScale(&drawOptions, progress)
+	return screen.DrawImage(to, &drawOptions)
+}
+
+// WipeDirection is the direction the new scene is revealed in
+type WipeDirection uint8
+
+const (
+	WipeLeftToRight WipeDirection = iota
+	WipeRightToLeft
+	WipeTopToBottom
+	WipeBottomToTop
+)
+
+type wipeTransition struct {
+	duration  float64
+	direction WipeDirection
+}
+
+// NewWipeTransition reveals the new scene over the old one with a moving edge
+func NewWipeTransition(duration float64, direction WipeDirection) SceneTransition {
+	return &wipeTransition{duration: duration, direction: direction}
+}
+
+func (w wipeTransition) Duration() float64 { return w.duration }
+
+func (w wipeTransition) Draw(screen, from, to *ebiten.Image, progress float64) error {
+	if err := screen.DrawImage(from, &ebiten.DrawImageOptions{}); err != nil {
+		return err
+	}
+	width, height := to.Size()
+	wipeW, wipeH := int(float64(width)*progress), int(float64(height)*progress)
+	var reveal image.Rectangle
+	switch w.direction {
+	case WipeLeftToRight:
+		reveal = image.Rect(0, 0, wipeW, height)
+	case WipeRightToLeft:
+		reveal = image.Rect(width-wipeW, 0, width, height)
+	case WipeTopToBottom:
+		reveal = image.Rect(0, 0, width, wipeH)
+	case WipeBottomToTop:
+		reveal = image.Rect(0, height-wipeH, width, height)
+	}
+	if reveal.Empty() {
+		return nil
+	}
+	drawOptions := ebiten.DrawImageOptions{}
+	drawOptions.GeoM.Translate(float64(reveal.Min.X), float64(reveal.Min.Y))
+	return screen.DrawImage(to.SubImage(reveal).(*ebiten.Image), &drawOptions)
+}
+
+// TransitionDrawFunc draws a custom transition
+type TransitionDrawFunc func(screen, from, to *ebiten.Image, progress float64) error
+
+type customTransition struct {
+	duration float64
+	draw     TransitionDrawFunc
+}
+
+func NewCustomTransition(duration float64, draw TransitionDrawFunc) SceneTransition {
+	return &customTransition{duration: duration, draw: draw}
+}
+
+func (c customTransition) Duration() float64 { return c.duration }
+
+func (c customTransition) Draw(screen, from, to *ebiten.Image, progress float64) error {
+	return c.draw(screen, from, to, progress)
+}
+
+// activeTransition is a transition that's currently playing on the scene manager
+type activeTransition struct {
+	transition SceneTransition
+	from       SceneId
+	elapsed    float64
+
+	fromImage, toImage *ebiten.Image
+}
+
+func (a activeTransition) Progress() float64 {
+	if a.transition.Duration() <= 0 {
+		return 1
+	}
+	progress := a.elapsed / a.transition.Duration()
+	if progress > 1 {
+		return 1
+	}
+	return progress
+}
+
+// buffers makes sure the offscreen renders match the screen size
+func (a *activeTransition) buffers(screen *ebiten.Image) error {
+	width, height := screen.Size()
+	if a.fromImage != nil {
+		if w, h := a.fromImage.Size(); w == width && h == height {
+			return nil
+		}
+	}
+	var err error
+	if a.fromImage, err = ebiten.NewImage(width, height, ebiten.FilterDefault); err != nil {
+		return err
+	}
+	if a.toImage, err = ebiten.NewImage(width, height, ebiten.FilterDefault); err != nil {
+		return err
+	}
+	return nil
+}
@@ -9,7 +9,32 @@ var (
 	ErrComponentPresent    = errors.New("component is already present")
 	ErrComponentNotPresent = errors.New("component is not present")
 
-	ErrScenePresent = errors.New("scene is already present")
+	ErrScenePresent     = errors.New("scene is already present")
+	ErrSceneStackEmpty  = errors.New("no scene is queued to transition to")
+	ErrTransitionActive = errors.New("a transition is already in progress")
+	ErrSceneNotPresent  = errors.New("scene is not present")
+	ErrNoOverlay        = errors.New("no overlay scene to pop")
+	ErrLoadActive       = errors.New("a scene is already loading")
+
+	ErrTooManyLayers   = errors.New("no collision layers left to define")
+	ErrLayerNotPresent = errors.New("collision layer is not present")
+
+	ErrActorPresent    = errors.New("actor is already present")
+	ErrChunkPresent    = errors.New("chunk is already loaded")
+	ErrChunkNotPresent = errors.New("chunk is not loaded")
+
+	ErrFlowStatePresent     = errors.New("flow state is already present")
+	ErrFlowStateNotPresent  = errors.New("flow state is not present")
+	ErrNoFlow               = errors.New("scene manager has no flow")
+	ErrUndeclaredTransition = errors.New("transition is not declared in the flow")
+	ErrTransitionGuarded    = errors.New("transition was refused by its guard")
+
+	ErrActionNotPresent = errors.New("action is not present")
+
+	ErrJointPresent     = errors.New("joint is already added")
+	ErrJointUnsupported = errors.New("joint was not made by this package")
+
+	ErrEffectorNotTrigger = errors.New("area effector's collider is not a trigger")
 )
 
 // ComponentType is an enum for ENGINE components. this defines what type of (default) component something is
@@ -25,6 +50,19 @@ const (
 
 	ComponentTypeSprite
 	ComponentTypeSpriteAnimated
+
+	ComponentTypeInputBuffer
+
+	ComponentTypeColliderAABB
+	ComponentTypeColliderCircle
+	ComponentTypeColliderPolygon
+	ComponentTypeColliderCapsule
+	ComponentTypeColliderPixel
+
+	ComponentTypeCharacterController
+	ComponentTypeAreaEffector
+	ComponentTypeVerlet
+	ComponentTypePathFollower
 )
 
 // ComponentSystem is an enum for ENGINE components. this defines what system uses the object
@@ -40,6 +78,7 @@ const (
 	ComponentSystemTransform
 	ComponentSystemGraphical
 	ComponentSystemPhysics
+	ComponentSystemCollider
 )
 
 // ComponentList is a bitmask containing info on what ENGINE components are present
@@ -78,3 +117,26 @@ type SceneId string
 
 // ActorId is the same
 type ActorId string
+
+// ActionId names an input action
+type ActionId string
+
+// SequenceId names an input sequence (combo, motion input)
+type SequenceId string
+
+// SubscriptionId identifies a shared data subscription so it can be removed
+type SubscriptionId uint64
+
+// FlowStateId names a state in a scene flow
+type FlowStateId string
+
+// ChunkId names a chunk of actors loaded additively into a scene
+type ChunkId string
+
+// CollisionLayerMask is a set of collision layers, one bit each
+type CollisionLayerMask uint32
+
+const (
+	DefaultCollisionLayer CollisionLayerMask = 1
+	AllCollisionLayers    CollisionLayerMask = 0xffffffff
+)
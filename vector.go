@@ -32,3 +32,56 @@ func (v *Vec2) Rotate(angle float64) {
 	v.Y = dist * math.Sin(curAng+angle)
 }
 func (v *Vec2) RotateDeg(angle float64) { v.Rotate(math.Pi * angle / 180) }
+
+func (v Vec2) Add(other Vec2) Vec2        { return Vec2{v.X + other.X, v.Y + other.Y} }
+func (v Vec2) Sub(other Vec2) Vec2        { return Vec2{v.X - other.X, v.Y - other.Y} }
+func (v Vec2) Scaled(scalar float64) Vec2 { return Vec2{v.X * scalar, v.Y * scalar} }
+func (v Vec2) Dot(other Vec2) float64     { return v.X*other.X + v.Y*other.Y }
+func (v Vec2) Cross(other Vec2) float64   { return v.X*other.Y - v.Y*other.X }
+func (v Vec2) Perp() Vec2                 { return Vec2{-v.Y, v.X} }
+
+func (v Vec2) Normalized() Vec2 {
+	length := v.Hypot()
+	if length == 0 {
+		return Vec2{}
+	}
+	return Vec2{v.X / length, v.Y / length}
+}
+
+// Rect is an axis aligned rectangle, mostly used for bounds
+type Rect struct {
+	Min, Max Vec2
+}
+
+func (r Rect) Size() Vec2   { return r.Max.Sub(r.Min) }
+func (r Rect) Center() Vec2 { return r.Min.Add(r.Max).Scaled(0.5) }
+
+func (r Rect) Overlaps(other Rect) bool {
+	return r.Min.X <= other.Max.X && r.Max.X >= other.Min.X && r.Min.Y <= other.Max.Y && r.Max.Y >= other.Min.Y
+}
+
+func (r Rect) Contains(p Vec2) bool {
+	return p.X >= r.Min.X && p.X <= r.Max.X && p.Y >= r.Min.Y && p.Y <= r.Max.Y
+}
+
+func (r Rect) Union(other Rect) Rect {
+	return Rect{
+		Min: Vec2{math.Min(r.Min.X, other.Min.X), math.Min(r.Min.Y, other.Min.Y)},
+		Max: Vec2{math.Max(r.Max.X, other.Max.X), math.Max(r.Max.Y, other.Max.Y)},
+	}
+}
+
+// Intersection is the rect both cover. only meaningful if they overlap
+func (r Rect) Intersection(other Rect) Rect {
+	return Rect{
+		Min: Vec2{math.Max(r.Min.X, other.Min.X), math.Max(r.Min.Y, other.Min.Y)},
+		Max: Vec2{math.Min(r.Max.X, other.Max.X), math.Min(r.Max.Y, other.Max.Y)},
+	}
+}
+
+func (r Rect) Expanded(amount float64) Rect {
+	return Rect{
+		Min: Vec2{r.Min.X - amount, r.Min.Y - amount},
+		Max: Vec2{r.Max.X + amount, r.Max.Y + amount},
+	}
+}
@@ -0,0 +1,454 @@
+package nagae
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten"
+)
+
+// VerletStyle is how a verlet component draws itself
+type VerletStyle uint8
+
+const (
+	VerletLines VerletStyle = iota // every stick as a line, in Color
+	VerletStrip                    // a textured strip through the points in order, ie ropes and hair
+	VerletMesh                     // textured triangles, ie cloth and banners
+)
+
+// VerletConfig tunes a verlet simulation and how it's drawn
+type VerletConfig struct {
+	Gravity    Vec2
+	Iterations int     // stick passes each update. more is stiffer
+	Damping    float64 // fraction of velocity lost each update
+
+	Collide     bool // push points out of the scene's (non trigger) colliders
+	Collision   QueryFilter
+	PointRadius float64 // how big points are when colliding
+
+	Style   VerletStyle
+	Width   float64 // lines and strips, in world units
+	Color   color.Color
+	Texture *ebiten.Image // strips and meshes. strips stretch it along their length
+}
+
+func DefaultVerletConfig() VerletConfig {
+	return VerletConfig{
+		Gravity:     Vec2{0, 10},
+		Iterations:  8,
+		Damping:     0.01,
+		PointRadius: 0.02,
+		Width:       0.02,
+		Color:       color.White,
+	}
+}
+
+// ComponentVerlet is a point and stick simulation for visual only soft things. it doesn't push on physics bodies.
+// points are in world space and don't follow the transform (which the actor still needs, to be drawn),
+// but they can be pinned to actors
+type ComponentVerlet interface {
+	Component
+	ComponentGraphicalBase
+
+	Config() VerletConfig
+	SetConfig(config VerletConfig)
+
+	AddPoint(position Vec2) int
+	AddStick(a, b int, stiffness float64) // rest length is the current distance. stiffness is 0 to 1
+	AddTriangle(a, b, c int)              // for VerletMesh
+	SetUV(point int, uv Vec2)             // texture coordinates, 0 to 1
+	Points() []Vec2
+
+	Pin(point int, actor *Actor, offset Vec2) // offset is local to the actor's transform
+	PinWorld(point int, position Vec2)
+	Unpin(point int)
+	Nudge(point int, velocity Vec2) // adds velocity, in world units per second
+}
+
+type verletPoint struct {
+	position, previous Vec2
+	uv                 Vec2
+
+	pinned    bool
+	pinActor  *Actor // nil for a world pin
+	pinOffset Vec2
+}
+
+type verletStick struct {
+	a, b      int
+	length    float64
+	stiffness float64
+}
+
+type componentVerletImpl struct {
+	ComponentGraphicalBaseImpl
+
+	config    VerletConfig
+	points    []verletPoint
+	sticks    []verletStick
+	triangles [][3]int
+	lastDt    float64
+}
+
+var verletBlank *ebiten.Image
+
+func (c componentVerletImpl) Config() VerletConfig           { return c.config }
+func (c *componentVerletImpl) SetConfig(config VerletConfig) { c.config = config }
+
+func (c *componentVerletImpl) AddPoint(position Vec2) int {
+	c.points = append(c.points, verletPoint{position: position, previous: position})
+	return len(c.points) - 1
+}
+
+func (c *componentVerletImpl) AddStick(a, b int, stiffness float64) {
+	c.sticks = append(c.sticks, verletStick{
+		a: a, b: b,
+		length:    c.points[b].position.Sub(c.points[a].position).Hypot(),
+		stiffness: math.Max(0, math.Min(1, stiffness)),
+	})
+}
+
+func (c *componentVerletImpl) AddTriangle(a, b, d int) {
+	c.triangles = append(c.triangles, [3]int{a, b, d})
+}
+func (c *componentVerletImpl) SetUV(point int, uv Vec2) { c.points[point].uv = uv }
+func (c *componentVerletImpl) Unpin(point int)          { c.points[point].pinned = false }
+func (c *componentVerletImpl) Nudge(point int, velocity Vec2) {
+	if c.lastDt > 0 {
+		c.points[point].previous.Translate(velocity.Scaled(-c.lastDt))
+	}
+}
+
+func (c componentVerletImpl) Points() []Vec2 {
+	points := make([]Vec2, len(c.points))
+	for i, p := range c.points {
+		points[i] = p.position
+	}
+	return points
+}
+
+func (c *componentVerletImpl) Pin(point int, actor *Actor, offset Vec2) {
+	p := &c.points[point]
+	p.pinned, p.pinActor, p.pinOffset = true, actor, offset
+}
+
+func (c *componentVerletImpl) PinWorld(point int, position Vec2) {
+	p := &c.points[point]
+	p.pinned, p.pinActor, p.pinOffset = true, nil, position
+}
+
+// pinPosition is where a pinned point is held. false if its actor has no transform
+func (p verletPoint) pinPosition() (Vec2, bool) {
+	if p.pinActor == nil {
+		return p.pinOffset, true
+	}
+	transformComp, present := p.pinActor.GetComponentBySystemType(ComponentSystemTransform)
+	if !present {
+		return Vec2{}, false
+	}
+	transform := transformComp.(ComponentTransform)
+	offset := p.pinOffset
+	offset.Rotate(transform.Rotation())
+	return transform.Position().Add(offset), true
+}
+
+func (c *componentVerletImpl) Update(dt float64) error {
+	if dt <= 0 {
+		return nil
+	}
+	c.lastDt = dt
+	keep := 1 - c.config.Damping
+	gravity := c.config.Gravity.Scaled(dt * dt)
+	for i := range c.points {
+		p := &c.points[i]
+		velocity := p.position.Sub(p.previous).Scaled(keep)
+		p.previous = p.position
+		p.position = p.position.Add(velocity).Add(gravity)
+	}
+
+	var collision *collisionSystemImpl
+	if c.config.Collide && c.boundActor != nil && c.boundActor.parentScene != nil {
+		collision, _ = c.boundActor.parentScene.collisionSystem.(*collisionSystemImpl)
+	}
+	for iteration := 0; iteration < c.config.Iterations; iteration++ {
+		c.holdPins()
+		for _, stick := range c.sticks {
+			a, b := &c.points[stick.a], &c.points[stick.b]
+			if a.pinned && b.pinned {
+				continue
+			}
+			d := b.position.Sub(a.position)
+			length := d.Hypot()
+			if length == 0 {
+				continue
+			}
+			correction := d.Scaled((length - stick.length) / length * stick.stiffness)
+			switch {
+			case a.pinned:
+				b.position = b.position.Sub(correction)
+			case b.pinned:
+				a.position = a.position.Add(correction)
+			default:
+				a.position = a.position.Add(correction.Scaled(0.5))
+				b.position = b.position.Sub(correction.Scaled(0.5))
+			}
+		}
+		if collision != nil {
+			c.collide(collision)
+		}
+	}
+	c.holdPins()
+	return nil
+}
+
+func (c *componentVerletImpl) holdPins() {
+	for i := range c.points {
+		p := &c.points[i]
+		if !p.pinned {
+			continue
+		}
+		if position, ok := p.pinPosition(); ok {
+			p.position = position
+		}
+	}
+}
+
+// collide pushes free points out of colliders
+func (c *componentVerletImpl) collide(collision *collisionSystemImpl) {
+	filter := c.config.Collision
+	filter.IncludeTriggers = false
+	for i := range c.points {
+		p := &c.points[i]
+		if p.pinned {
+			continue
+		}
+		shape := convexShape{points: []Vec2{p.position}, radius: c.config.PointRadius}
+		for _, entry := range collision.candidates(shape.bounds(), filter) {
+			if manifold, hit := collideShapes(shape, entry.shape); hit {
+				p.position = p.position.Sub(manifold.Normal.Scaled(manifold.Depth))
+				shape.points[0] = p.position
+			}
+		}
+	}
+}
+
+func (c *componentVerletImpl) Draw(screen *ebiten.Image) error {
+	if len(c.points) == 0 {
+		return nil
+	}
+	switch c.config.Style {
+	case VerletStrip:
+		if c.config.Texture != nil {
+			return c.drawStrip(screen)
+		}
+	case VerletMesh:
+		if c.config.Texture != nil {
+			return c.drawMesh(screen)
+		}
+	}
+	return c.drawLines(screen)
+}
+
+func vertexAt(position Vec2, srcX, srcY float64, clr color.Color) ebiten.Vertex {
+	x, y := WorldToScreen(position)
+	r, g, b, a := clr.RGBA()
+	return ebiten.Vertex{
+		DstX: float32(x), DstY: float32(y),
+		SrcX: float32(srcX), SrcY: float32(srcY),
+		ColorR: float32(r) / 0xffff, ColorG: float32(g) / 0xffff, ColorB: float32(b) / 0xffff, ColorA: float32(a) / 0xffff,
+	}
+}
+
+// triangleBatch splits triangles into as many DrawTriangles calls as it takes to keep within uint16 indices
+type triangleBatch struct {
+	screen, img *ebiten.Image
+	vertices    []ebiten.Vertex
+	indices     []uint16
+	local       map[int]uint16 // shared vertex to where it is in this batch
+}
+
+func newTriangleBatch(screen, img *ebiten.Image) *triangleBatch {
+	return &triangleBatch{screen: screen, img: img, local: make(map[int]uint16)}
+}
+
+// room flushes first if the batch can't take this many more vertices and indices
+func (b *triangleBatch) room(vertices, indices int) {
+	if len(b.vertices)+vertices > 1<<16 || len(b.indices)+indices > ebiten.MaxIndicesNum {
+		b.flush()
+	}
+}
+
+func (b *triangleBatch) flush() {
+	if len(b.indices) > 0 {
+		b.screen.DrawTriangles(b.vertices, b.indices, b.img, nil)
+	}
+	b.vertices, b.indices = b.vertices[:0], b.indices[:0]
+	b.local = make(map[int]uint16)
+}
+
+// quad adds a, b, c, d as the triangles a b c and b d c
+func (b *triangleBatch) quad(v0, v1, v2, v3 ebiten.Vertex) {
+	b.room(4, 6)
+	base := uint16(len(b.vertices))
+	b.vertices = append(b.vertices, v0, v1, v2, v3)
+	b.indices = append(b.indices, base, base+1, base+2, base+1, base+3, base+2)
+}
+
+// triangle adds a triangle of shared vertices, copying each into the batch the first time it's used there
+func (b *triangleBatch) triangle(triangle [3]int, vertex func(i int) ebiten.Vertex) {
+	b.room(3, 3)
+	for _, i := range triangle {
+		index, present := b.local[i]
+		if !present {
+			index = uint16(len(b.vertices))
+			b.vertices = append(b.vertices, vertex(i))
+			b.local[i] = index
+		}
+		b.indices = append(b.indices, index)
+	}
+}
+
+func (c *componentVerletImpl) drawLines(screen *ebiten.Image) error {
+	if verletBlank == nil {
+		blank, err := ebiten.NewImage(3, 3, ebiten.FilterDefault)
+		if err != nil {
+			return err
+		}
+		if err := blank.Fill(color.White); err != nil {
+			return err
+		}
+		verletBlank = blank
+	}
+	clr := c.config.Color
+	if clr == nil {
+		clr = color.White
+	}
+	batch := newTriangleBatch(screen, verletBlank)
+	for _, stick := range c.sticks {
+		a, b := c.points[stick.a].position, c.points[stick.b].position
+		side := b.Sub(a).Perp().Normalized().Scaled(c.config.Width / 2)
+		batch.quad(vertexAt(a.Add(side), 1, 1, clr), vertexAt(a.Sub(side), 1, 2, clr),
+			vertexAt(b.Add(side), 2, 1, clr), vertexAt(b.Sub(side), 2, 2, clr))
+	}
+	batch.flush()
+	return nil
+}
+
+// drawStrip runs the texture along the points in order, u along the strip and v across it
+func (c *componentVerletImpl) drawStrip(screen *ebiten.Image) error {
+	if len(c.points) < 2 {
+		return nil
+	}
+	w, h := c.config.Texture.Size()
+	total := 0.0
+	for i := 1; i < len(c.points); i++ {
+		total += c.points[i].position.Sub(c.points[i-1].position).Hypot()
+	}
+	batch := newTriangleBatch(screen, c.config.Texture)
+	var prevOuter, prevInner ebiten.Vertex
+	along := 0.0
+	for i, p := range c.points {
+		// the strip's side at a point is square to the points either side of it
+		prev, next := c.points[int(math.Max(0, float64(i-1)))].position, c.points[int(math.Min(float64(len(c.points)-1), float64(i+1)))].position
+		side := next.Sub(prev).Perp().Normalized().Scaled(c.config.Width / 2)
+		if i > 0 {
+			along += p.position.Sub(c.points[i-1].position).Hypot()
+		}
+		u := 0.0
+		if total > 0 {
+			u = along / total * float64(w)
+		}
+		outer, inner := vertexAt(p.position.Add(side), u, 0, color.White), vertexAt(p.position.Sub(side), u, float64(h), color.White)
+		if i > 0 {
+			batch.quad(prevOuter, prevInner, outer, inner)
+		}
+		prevOuter, prevInner = outer, inner
+	}
+	batch.flush()
+	return nil
+}
+
+func (c *componentVerletImpl) drawMesh(screen *ebiten.Image) error {
+	w, h := c.config.Texture.Size()
+	batch := newTriangleBatch(screen, c.config.Texture)
+	vertex := func(i int) ebiten.Vertex {
+		p := c.points[i]
+		return vertexAt(p.position, p.uv.X*float64(w), p.uv.Y*float64(h), color.White)
+	}
+	for _, triangle := range c.triangles {
+		batch.triangle(triangle, vertex)
+	}
+	batch.flush()
+	return nil
+}
+
+func NewComponentVerlet(drawOrderPos int, config VerletConfig) (ComponentVerlet, error) {
+	baseComponent, err := NewComponentGraphicalRaw("verlet", drawOrderPos)
+	if err != nil {
+		return nil, err
+	}
+	baseComponent.(*ComponentGraphicalBaseImpl).cType = ComponentTypeVerlet
+	return &componentVerletImpl{
+		ComponentGraphicalBaseImpl: *baseComponent.(*ComponentGraphicalBaseImpl),
+		config:                     config,
+		points:                     make([]verletPoint, 0),
+		sticks:                     make([]verletStick, 0),
+		triangles:                  make([][3]int, 0),
+	}, nil
+}
+
+// NewComponentRope is a chain of segments from start to end. nothing is pinned
+func NewComponentRope(drawOrderPos int, config VerletConfig, start, end Vec2, segments int) (ComponentVerlet, error) {
+	if segments < 1 {
+		segments = 1
+	}
+	rope, err := NewComponentVerlet(drawOrderPos, config)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i <= segments; i++ {
+		t := float64(i) / float64(segments)
+		point := rope.AddPoint(start.Add(end.Sub(start).Scaled(t)))
+		rope.SetUV(point, Vec2{t, 0.5})
+		if i > 0 {
+			rope.AddStick(point-1, point, 1)
+		}
+	}
+	return rope, nil
+}
+
+// NewComponentCloth is a grid of points from topLeft, with structural and shear sticks and triangles
+// for drawing as a mesh. points go row by row, so the top row is 0 to columns. nothing is pinned
+func NewComponentCloth(drawOrderPos int, config VerletConfig, topLeft, size Vec2, columns, rows int) (ComponentVerlet, error) {
+	if columns < 1 {
+		columns = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	cloth, err := NewComponentVerlet(drawOrderPos, config)
+	if err != nil {
+		return nil, err
+	}
+	at := func(x, y int) int { return y*(columns+1) + x }
+	for y := 0; y <= rows; y++ {
+		for x := 0; x <= columns; x++ {
+			uv := Vec2{float64(x) / float64(columns), float64(y) / float64(rows)}
+			point := cloth.AddPoint(topLeft.Add(Vec2{uv.X * size.X, uv.Y * size.Y}))
+			cloth.SetUV(point, uv)
+			if x > 0 {
+				cloth.AddStick(at(x-1, y), point, 1)
+			}
+			if y > 0 {
+				cloth.AddStick(at(x, y-1), point, 1)
+			}
+			if x > 0 && y > 0 {
+				cloth.AddStick(at(x-1, y-1), point, 0.5)
+				cloth.AddStick(at(x, y-1), at(x-1, y), 0.5)
+				cloth.AddTriangle(at(x-1, y-1), at(x, y-1), at(x-1, y))
+				cloth.AddTriangle(at(x, y-1), point, at(x-1, y))
+			}
+		}
+	}
+	return cloth, nil
+}